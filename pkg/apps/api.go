@@ -72,6 +72,19 @@ func (a *Apps) handleAPI(app starr.App, api APIHandler) http.HandlerFunc { //nol
 			msg = fmt.Errorf("%v: %w", aID, ErrNoReadarr)
 		case app == starr.Sonarr && (aID >= len(a.Sonarr) || aID < 0):
 			msg = fmt.Errorf("%v: %w", aID, ErrNoSonarr)
+		case app == Deluge && (aID >= len(a.Deluge) || aID < 0 || !a.Deluge[aID].Enabled()):
+			msg = fmt.Errorf("%v: %w", aID, ErrNoDeluge)
+		case app == Qbit && (aID >= len(a.Qbit) || aID < 0 || !a.Qbit[aID].Enabled()):
+			msg = fmt.Errorf("%v: %w", aID, ErrNoQbit)
+		case app == Rtorrent && (aID >= len(a.Rtorrent) || aID < 0 || !a.Rtorrent[aID].Enabled()):
+			msg = fmt.Errorf("%v: %w", aID, ErrNoRtorrent)
+		case app == Transmission && (aID >= len(a.Transmission) || aID < 0 ||
+			!a.Transmission[aID].Enabled() || a.Transmission[aID].Client == nil):
+			msg = fmt.Errorf("%v: %w", aID, ErrNoTransmission)
+		case app == SABnzbd && (aID >= len(a.SabNZB) || aID < 0 || !a.SabNZB[aID].Enabled()):
+			msg = fmt.Errorf("%v: %w", aID, ErrNoSABnzbd)
+		case app == NZBGet && (aID >= len(a.NZBGet) || aID < 0 || !a.NZBGet[aID].Enabled()):
+			msg = fmt.Errorf("%v: %w", aID, ErrNoNZBGet)
 			// Store the application configuration (starr) in a context then pass that into the api() method.
 			// Retrieve the return code and output, and send a response via a.Respond().
 		case app == starr.Lidarr:
@@ -84,6 +97,18 @@ func (a *Apps) handleAPI(app starr.App, api APIHandler) http.HandlerFunc { //nol
 			code, msg = api(r.WithContext(context.WithValue(ctx, app, a.Readarr[aID])))
 		case app == starr.Sonarr:
 			code, msg = api(r.WithContext(context.WithValue(ctx, app, a.Sonarr[aID])))
+		case app == Deluge:
+			code, msg = api(r.WithContext(context.WithValue(ctx, app, a.Deluge[aID])))
+		case app == Qbit:
+			code, msg = api(r.WithContext(context.WithValue(ctx, app, a.Qbit[aID])))
+		case app == Rtorrent:
+			code, msg = api(r.WithContext(context.WithValue(ctx, app, a.Rtorrent[aID])))
+		case app == Transmission:
+			code, msg = api(r.WithContext(context.WithValue(ctx, app, a.Transmission[aID])))
+		case app == SABnzbd:
+			code, msg = api(r.WithContext(context.WithValue(ctx, app, a.SabNZB[aID])))
+		case app == NZBGet:
+			code, msg = api(r.WithContext(context.WithValue(ctx, app, a.NZBGet[aID])))
 		case app == "":
 			// no app, just run the handler.
 			code, msg = api(r) // unknown app, just run the handler.
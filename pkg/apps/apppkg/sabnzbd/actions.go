@@ -0,0 +1,167 @@
+package sabnzbd
+
+/* This file contains the SABnzbd API calls that change the queue or history. */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrRequestFailed is returned when SABnzbd reports a failed request.
+var ErrRequestFailed = errors.New("sabnzbd request failed")
+
+// Priority is the priority of a job in the queue.
+type Priority int
+
+// These are the SABnzbd job priorities.
+const (
+	PriorityPaused Priority = -2
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+	PriorityForce  Priority = 2
+)
+
+// status is the response from SABnzbd for most actions.
+type status struct {
+	Status bool   `json:"status"`
+	Error  string `json:"error"`
+}
+
+// params returns the parameters needed for every API request.
+func (s *Config) params(mode string) url.Values {
+	params := url.Values{}
+	params.Add("output", "json")
+	params.Add("mode", mode)
+	params.Add("apikey", s.APIKey)
+
+	return params
+}
+
+// action makes an API request and checks the status in the response.
+func (s *Config) action(ctx context.Context, params url.Values) error {
+	var output status
+
+	if err := s.GetURLInto(ctx, params, &output); err != nil {
+		return err
+	}
+
+	if !output.Status {
+		return fmt.Errorf("%w: %s: %s", ErrRequestFailed, params.Get("mode"), output.Error)
+	}
+
+	return nil
+}
+
+// PauseQueue pauses the download queue.
+func (s *Config) PauseQueue(ctx context.Context) error {
+	return s.action(ctx, s.params("pause"))
+}
+
+// ResumeQueue resumes the download queue.
+func (s *Config) ResumeQueue(ctx context.Context) error {
+	return s.action(ctx, s.params("resume"))
+}
+
+// SetSpeedLimit sets the download speed limit in kilobytes per second. Zero removes the limit.
+func (s *Config) SetSpeedLimit(ctx context.Context, kbps int64) error {
+	params := s.params("config")
+	params.Add("name", "speedlimit")
+
+	if kbps > 0 {
+		params.Add("value", strconv.FormatInt(kbps, 10)+"K")
+	} else {
+		params.Add("value", "0")
+	}
+
+	return s.action(ctx, params)
+}
+
+// SetPriority sets the priority of a job in the queue, and returns its new position.
+func (s *Config) SetPriority(ctx context.Context, nzoID string, priority Priority) (int, error) {
+	params := s.params("queue")
+	params.Add("name", "priority")
+	params.Add("value", nzoID)
+	params.Add("value2", strconv.Itoa(int(priority)))
+
+	var output struct {
+		Position int `json:"position"`
+	}
+
+	if err := s.GetURLInto(ctx, params, &output); err != nil {
+		return 0, err
+	}
+
+	if output.Position < 0 {
+		return output.Position, fmt.Errorf("%w: priority: job not found: %s", ErrRequestFailed, nzoID)
+	}
+
+	return output.Position, nil
+}
+
+// MoveJob moves a job to a position in the queue. The first position is 0.
+func (s *Config) MoveJob(ctx context.Context, nzoID string, position int) error {
+	params := s.params("switch")
+	params.Add("value", nzoID)
+	params.Add("value2", strconv.Itoa(position))
+
+	var output struct {
+		Result struct {
+			Position int `json:"position"`
+		} `json:"result"`
+	}
+
+	if err := s.GetURLInto(ctx, params, &output); err != nil {
+		return err
+	}
+
+	if output.Result.Position < 0 {
+		return fmt.Errorf("%w: switch: job not found: %s", ErrRequestFailed, nzoID)
+	}
+
+	return nil
+}
+
+// DeleteJobs deletes jobs from the queue. Set deleteFiles to also delete the files already downloaded.
+func (s *Config) DeleteJobs(ctx context.Context, deleteFiles bool, nzoIDs ...string) error {
+	params := s.params("queue")
+	params.Add("name", "delete")
+	params.Add("value", strings.Join(nzoIDs, ","))
+
+	if deleteFiles {
+		params.Add("del_files", "1")
+	}
+
+	return s.action(ctx, params)
+}
+
+// RetryJob retries a failed job in the history.
+func (s *Config) RetryJob(ctx context.Context, nzoID string) error {
+	params := s.params("retry")
+	params.Add("value", nzoID)
+
+	return s.action(ctx, params)
+}
+
+// PurgeHistory deletes every job in the history, or only the failed jobs.
+// Set deleteFiles to also delete the files of failed jobs.
+func (s *Config) PurgeHistory(ctx context.Context, failedOnly, deleteFiles bool) error {
+	params := s.params("history")
+	params.Add("name", "delete")
+
+	if failedOnly {
+		params.Add("value", "failed")
+	} else {
+		params.Add("value", "all")
+	}
+
+	if deleteFiles {
+		params.Add("del_files", "1")
+	}
+
+	return s.action(ctx, params)
+}
@@ -0,0 +1,122 @@
+package sabnzbd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps/apppkg/sabnzbd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSAB returns a config for a fake SABnzbd server that replies with the provided body.
+// The query of the last request is written to the provided pointer.
+func fakeSAB(t *testing.T, body string, query *url.Values) *sabnzbd.Config {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api" || req.URL.Query().Get("apikey") != "key" {
+			http.Error(resp, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		*query = req.URL.Query()
+		_, _ = resp.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return &sabnzbd.Config{URL: server.URL, APIKey: "key", Client: server.Client()}
+}
+
+func TestQueueActions(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var query url.Values
+
+	sab := fakeSAB(t, `{"status": true}`, &query)
+
+	require.NoError(t, sab.PauseQueue(ctx))
+	assert.Equal("pause", query.Get("mode"))
+	assert.Equal("json", query.Get("output"))
+
+	require.NoError(t, sab.ResumeQueue(ctx))
+	assert.Equal("resume", query.Get("mode"))
+
+	require.NoError(t, sab.SetSpeedLimit(ctx, 2048))
+	assert.Equal("config", query.Get("mode"))
+	assert.Equal("speedlimit", query.Get("name"))
+	assert.Equal("2048K", query.Get("value"))
+
+	require.NoError(t, sab.SetSpeedLimit(ctx, 0))
+	assert.Equal("0", query.Get("value"), "zero must remove the limit")
+
+	require.NoError(t, sab.DeleteJobs(ctx, true, "SABnzbd_nzo_1", "SABnzbd_nzo_2"))
+	assert.Equal("queue", query.Get("mode"))
+	assert.Equal("delete", query.Get("name"))
+	assert.Equal("SABnzbd_nzo_1,SABnzbd_nzo_2", query.Get("value"))
+	assert.Equal("1", query.Get("del_files"))
+}
+
+func TestHistoryActions(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var query url.Values
+
+	sab := fakeSAB(t, `{"status": true}`, &query)
+
+	require.NoError(t, sab.RetryJob(ctx, "SABnzbd_nzo_1"))
+	assert.Equal("retry", query.Get("mode"))
+	assert.Equal("SABnzbd_nzo_1", query.Get("value"))
+
+	require.NoError(t, sab.PurgeHistory(ctx, true, false))
+	assert.Equal("history", query.Get("mode"))
+	assert.Equal("delete", query.Get("name"))
+	assert.Equal("failed", query.Get("value"))
+	assert.Empty(query.Get("del_files"))
+
+	require.NoError(t, sab.PurgeHistory(ctx, false, true))
+	assert.Equal("all", query.Get("value"))
+	assert.Equal("1", query.Get("del_files"))
+}
+
+func TestJobPosition(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var query url.Values
+
+	position, err := fakeSAB(t, `{"position": 3}`, &query).SetPriority(ctx, "SABnzbd_nzo_1", sabnzbd.PriorityHigh)
+	require.NoError(t, err)
+	assert.Equal(3, position)
+	assert.Equal("priority", query.Get("name"))
+	assert.Equal("1", query.Get("value2"))
+
+	_, err = fakeSAB(t, `{"position": -1}`, &query).SetPriority(ctx, "missing", sabnzbd.PriorityLow)
+	require.ErrorIs(t, err, sabnzbd.ErrRequestFailed, "a missing job must return an error")
+
+	sab := fakeSAB(t, `{"result": {"priority": 0, "position": 5}}`, &query)
+	require.NoError(t, sab.MoveJob(ctx, "SABnzbd_nzo_1", 5))
+	assert.Equal("switch", query.Get("mode"))
+	assert.Equal("5", query.Get("value2"))
+
+	sab = fakeSAB(t, `{"result": {"priority": 0, "position": -1}}`, &query)
+	require.ErrorIs(t, sab.MoveJob(ctx, "missing", 0), sabnzbd.ErrRequestFailed)
+}
+
+func TestFailedAction(t *testing.T) {
+	t.Parallel()
+
+	var query url.Values
+
+	err := fakeSAB(t, `{"status": false, "error": "not allowed"}`, &query).PauseQueue(context.Background())
+	require.ErrorIs(t, err, sabnzbd.ErrRequestFailed)
+	assert.Contains(t, err.Error(), "not allowed")
+}
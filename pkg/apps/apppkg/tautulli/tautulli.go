@@ -3,6 +3,7 @@ package tautulli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -114,6 +115,42 @@ func (c *Config) GetInfo(ctx context.Context) (*Info, error) {
 	return output.Resp.Data, nil
 }
 
+// ErrDownloadFailed is returned when Tautulli does not return its database file.
+var ErrDownloadFailed = errors.New("downloading database failed")
+
+// DownloadDatabase writes a copy of the Tautulli database to output, and returns its size.
+func (c *Config) DownloadDatabase(ctx context.Context, output io.Writer) (int64, error) {
+	params := url.Values{}
+	params.Add("cmd", "download_database")
+	params.Add("apikey", c.APIKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+"/api/v2", nil)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.URL.RawQuery = params.Encode()
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%s", strings.ReplaceAll(err.Error(), c.APIKey, "<redacted>")) //nolint:goerr113
+	}
+	defer resp.Body.Close()
+
+	// Errors come back as json; the database comes back as a file.
+	if resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, mnd.Kilobyte))
+		return 0, fmt.Errorf("%w (%s): %s", ErrDownloadFailed, resp.Status, string(body))
+	}
+
+	size, err := io.Copy(output, resp.Body)
+	if err != nil {
+		return size, fmt.Errorf("writing database file: %w", err)
+	}
+
+	return size, nil
+}
+
 // Users is the entire get_users API response.
 type Users struct {
 	Response struct {
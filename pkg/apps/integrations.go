@@ -25,6 +25,8 @@ type PlexConfig struct {
 	*plex.Config
 	*plex.Server
 	ExtraConfig
+	// BackupPath is the local folder with Plex's scheduled database backups. Enables database backup checks.
+	BackupPath string `json:"backupPath" toml:"backup_path" xml:"backup_path"`
 }
 
 func (c *PlexConfig) Setup(maxBody int, logger mnd.Logger) {
@@ -56,6 +58,8 @@ func (c *PlexConfig) Enabled() bool {
 type TautulliConfig struct {
 	ExtraConfig
 	tautulli.Config
+	// BackupPath is the local folder with Tautulli's database backups. Enables database backup checks.
+	BackupPath string `json:"backupPath" toml:"backup_path" xml:"backup_path"`
 }
 
 func (c *TautulliConfig) Setup(maxBody int, logger mnd.Logger) {
@@ -0,0 +1,55 @@
+package apps
+
+import (
+	"strings"
+	"time"
+
+	"golift.io/cnfg"
+)
+
+// Remediation is an opt-in local policy that removes stuck queue items from a Lidarr,
+// Radarr, Readarr or Sonarr instance. Each removal counts against the instance's deletes limit.
+//   - Status matches the item's status or tracked download state, ie. failed, warning, importBlocked.
+//     Empty matches every stuck item.
+//   - StuckFor is how long an item must be stuck before this policy removes it.
+//   - Remove deletes the download from the download client.
+//   - Blocklist adds the release to the blocklist, and Search looks for a replacement (requires Blocklist).
+//   - DryRun logs and reports what would happen, but does not remove anything.
+type Remediation struct {
+	Status    []string      `json:"status"    toml:"status"    xml:"status"    yaml:"status"`
+	StuckFor  cnfg.Duration `json:"stuckFor"  toml:"stuck_for" xml:"stuck_for" yaml:"stuckFor"`
+	Remove    bool          `json:"remove"    toml:"remove"    xml:"remove"    yaml:"remove"`
+	Blocklist bool          `json:"blocklist" toml:"blocklist" xml:"blocklist" yaml:"blocklist"`
+	Search    bool          `json:"search"    toml:"search"    xml:"search"    yaml:"search"`
+	DryRun    bool          `json:"dryRun"    toml:"dry_run"   xml:"dry_run"   yaml:"dryRun"`
+}
+
+// Matches returns true if a queue item with this status and state has been stuck long enough for the policy.
+func (r *Remediation) Matches(status, state string, stuck time.Duration) bool {
+	if stuck < r.StuckFor.Duration {
+		return false
+	}
+
+	if len(r.Status) == 0 {
+		return true
+	}
+
+	for _, match := range r.Status {
+		if strings.EqualFold(match, status) || strings.EqualFold(match, state) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Remediation returns the first remediation policy that matches a stuck queue item.
+func (e *ExtraConfig) Remediation(status, state string, stuck time.Duration) *Remediation {
+	for _, policy := range e.Remediate {
+		if policy != nil && policy.Matches(status, state, stuck) {
+			return policy
+		}
+	}
+
+	return nil
+}
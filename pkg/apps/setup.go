@@ -48,13 +48,19 @@ type Apps struct {
 	compress     func(h http.Handler) http.Handler
 }
 
+// ExtraConfig contains settings shared by many apps. These are only used by Lidarr, Radarr, Readarr and Sonarr:
+//   - Priority decides which instance keeps a download that is queued in more than one instance. Highest wins.
+//   - RemoveDupes removes this instance's copy of a duplicate download when another instance has a higher priority.
 type ExtraConfig struct {
-	Name     string        `json:"name"     toml:"name"      xml:"name"`
-	Timeout  cnfg.Duration `json:"timeout"  toml:"timeout"   xml:"timeout"`
-	Interval cnfg.Duration `json:"interval" toml:"interval"  xml:"interval"`
-	ValidSSL bool          `json:"validSsl" toml:"valid_ssl" xml:"valid_ssl"`
-	Deletes  int           `json:"deletes"  toml:"deletes"   xml:"deletes"`
-	delLimit *rate.Limiter
+	Name        string         `json:"name"        toml:"name"              xml:"name"`
+	Timeout     cnfg.Duration  `json:"timeout"     toml:"timeout"           xml:"timeout"`
+	Interval    cnfg.Duration  `json:"interval"    toml:"interval"          xml:"interval"`
+	ValidSSL    bool           `json:"validSsl"    toml:"valid_ssl"         xml:"valid_ssl"`
+	Deletes     int            `json:"deletes"     toml:"deletes"           xml:"deletes"`
+	Remediate   []*Remediation `json:"remediate"   toml:"remediate"         xml:"remediate"`
+	Priority    int            `json:"priority"    toml:"priority"          xml:"priority"`
+	RemoveDupes bool           `json:"removeDupes" toml:"remove_duplicates" xml:"remove_duplicates"`
+	delLimit    *rate.Limiter
 }
 
 // Errors sent to client web requests.
@@ -154,6 +160,8 @@ func (a *Apps) InitHandlers() {
 	a.radarrHandlers()
 	a.readarrHandlers()
 	a.sonarrHandlers()
+	a.torrentHandlers()
+	a.usenetHandlers()
 }
 
 // DelOK returns true if the delete limit isn't reached.
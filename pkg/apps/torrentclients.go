@@ -0,0 +1,566 @@
+package apps
+
+/* This file contains the torrent client methods used by the torrent API handlers in torrents.go. */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/mrobinsn/go-rtorrent/rtorrent"
+)
+
+// Deluge uses KiB/s for speed limits, Transmission uses kB/s.
+const (
+	kibibyte = 1024
+	kilobyte = 1000
+)
+
+// kilobytes converts a speed limit in bytes to the unit provided.
+// Zero remains zero (unlimited), everything else is at least 1.
+func kilobytes(limit, unit int64) int64 {
+	if limit <= 0 {
+		return 0
+	}
+
+	return max(1, limit/unit)
+}
+
+// lowerHashes returns the hashes lower-cased. Starr apps use upper-case download IDs.
+func lowerHashes(hashes []string) []string {
+	output := make([]string, len(hashes))
+	for idx, hash := range hashes {
+		output[idx] = strings.ToLower(hash)
+	}
+
+	return output
+}
+
+/* Deluge. These use Deluge 2 RPC methods. */
+
+func (c *DelugeConfig) torrents(ctx context.Context) ([]*Torrent, error) {
+	xfers, err := c.GetXfersCompatContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting transfers: %w", err)
+	}
+
+	torrents := []*Torrent{}
+
+	for _, xfer := range xfers {
+		torrents = append(torrents, &Torrent{
+			Hash:       xfer.Hash,
+			Name:       xfer.Name,
+			State:      xfer.State,
+			Category:   xfer.Label,
+			Size:       int64(xfer.TotalSize),
+			Progress:   xfer.Progress,
+			Downloaded: int64(xfer.TotalDone),
+			Uploaded:   int64(xfer.TotalUploaded),
+			DownRate:   int64(xfer.DownloadPayloadRate),
+			UpRate:     int64(xfer.UploadPayloadRate),
+			Ratio:      xfer.Ratio,
+			Path:       xfer.SavePath,
+			Paused:     xfer.Paused,
+			Message:    xfer.Message,
+			Added:      time.Unix(int64(xfer.TimeAdded), 0),
+		})
+	}
+
+	return torrents, nil
+}
+
+func (c *DelugeConfig) action(ctx context.Context, action string, hashes []string) error {
+	method := map[string]string{
+		torrentPause:   "core.pause_torrents",
+		torrentResume:  "core.resume_torrents",
+		torrentRecheck: "core.force_recheck",
+	}[action]
+
+	if _, err := c.Get(ctx, method, []interface{}{lowerHashes(hashes)}); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	return nil
+}
+
+func (c *DelugeConfig) remove(ctx context.Context, hashes []string, deleteData bool) error {
+	for _, hash := range lowerHashes(hashes) {
+		if _, err := c.Get(ctx, "core.remove_torrent", []interface{}{hash, deleteData}); err != nil {
+			return fmt.Errorf("core.remove_torrent: %s: %w", hash, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *DelugeConfig) category(ctx context.Context, hashes []string, category string) error {
+	category = strings.ToLower(category) // deluge labels are lower case.
+
+	if category != "" {
+		// This returns an error if the label exists, so ignore it. Setting the label will fail if it's a real error.
+		_, _ = c.Get(ctx, "label.add", []string{category})
+	}
+
+	for _, hash := range lowerHashes(hashes) {
+		if _, err := c.Get(ctx, "label.set_torrent", []string{hash, category}); err != nil {
+			return fmt.Errorf("label.set_torrent: %s: %w", hash, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *DelugeConfig) limits(ctx context.Context, hashes []string, limits *SpeedLimits) error {
+	// Deluge uses -1 for unlimited.
+	download, upload := kilobytes(limits.Download, kibibyte), kilobytes(limits.Upload, kibibyte)
+	if download == 0 {
+		download = -1
+	}
+
+	if upload == 0 {
+		upload = -1
+	}
+
+	options := map[string]int64{"max_download_speed": download, "max_upload_speed": upload}
+	if _, err := c.Get(ctx, "core.set_torrent_options", []interface{}{lowerHashes(hashes), options}); err != nil {
+		return fmt.Errorf("core.set_torrent_options: %w", err)
+	}
+
+	return nil
+}
+
+/* qBittorrent. The qbit library only has methods to list torrents and set categories. */
+
+// errQbitNoPath is returned when qBittorrent does not have an API path; it's used to detect version 5.
+var errQbitNoPath = errors.New("api path not found")
+
+func (c *QbitConfig) torrents(ctx context.Context) ([]*Torrent, error) {
+	xfers, err := c.GetXfersContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting transfers: %w", err)
+	}
+
+	torrents := []*Torrent{}
+
+	for _, xfer := range xfers {
+		torrents = append(torrents, &Torrent{
+			Hash:       xfer.Hash,
+			Name:       xfer.Name,
+			State:      xfer.State,
+			Category:   xfer.Category,
+			Size:       xfer.Size,
+			Progress:   xfer.Progress * 100, //nolint:mnd // convert to percent.
+			Downloaded: int64(xfer.Completed),
+			Uploaded:   xfer.Uploaded,
+			DownRate:   int64(xfer.Dlspeed),
+			UpRate:     xfer.Upspeed,
+			Ratio:      xfer.Ratio,
+			Path:       xfer.SavePath,
+			Paused:     strings.HasPrefix(xfer.State, "paused") || strings.HasPrefix(xfer.State, "stopped"),
+			Added:      time.Unix(int64(xfer.AddedOn), 0),
+		})
+	}
+
+	return torrents, nil
+}
+
+func (c *QbitConfig) action(ctx context.Context, action string, hashes []string) error {
+	values := url.Values{"hashes": []string{strings.Join(lowerHashes(hashes), "|")}}
+
+	switch action {
+	case torrentRecheck:
+		return c.post(ctx, "api/v2/torrents/recheck", values)
+	case torrentPause:
+		// qBittorrent 5 renamed pause and resume to stop and start.
+		if err := c.post(ctx, "api/v2/torrents/pause", values); !errors.Is(err, errQbitNoPath) {
+			return err
+		}
+
+		return c.post(ctx, "api/v2/torrents/stop", values)
+	default:
+		if err := c.post(ctx, "api/v2/torrents/resume", values); !errors.Is(err, errQbitNoPath) {
+			return err
+		}
+
+		return c.post(ctx, "api/v2/torrents/start", values)
+	}
+}
+
+func (c *QbitConfig) remove(ctx context.Context, hashes []string, deleteData bool) error {
+	return c.post(ctx, "api/v2/torrents/delete", url.Values{
+		"hashes":      []string{strings.Join(lowerHashes(hashes), "|")},
+		"deleteFiles": []string{fmt.Sprint(deleteData)},
+	})
+}
+
+func (c *QbitConfig) category(ctx context.Context, hashes []string, category string) error {
+	if err := c.SetTorrentCategoryContext(ctx, category, lowerHashes(hashes)...); err != nil {
+		return fmt.Errorf("setting category: %w", err)
+	}
+
+	return nil
+}
+
+func (c *QbitConfig) limits(ctx context.Context, hashes []string, limits *SpeedLimits) error {
+	hash := strings.Join(lowerHashes(hashes), "|")
+
+	err := c.post(ctx, "api/v2/torrents/setDownloadLimit", url.Values{
+		"hashes": []string{hash},
+		"limit":  []string{fmt.Sprint(limits.Download)},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.post(ctx, "api/v2/torrents/setUploadLimit", url.Values{
+		"hashes": []string{hash},
+		"limit":  []string{fmt.Sprint(limits.Upload)},
+	})
+}
+
+// post sends a form to a qBittorrent API path that returns no data.
+// This uses the library's http client because it has the login cookie.
+func (c *QbitConfig) post(ctx context.Context, path string, values url.Values) error {
+	status, err := c.postForm(ctx, path, values)
+	if err == nil && status == http.StatusForbidden {
+		// Not logged in. The library logs in when a request fails, so make one, then try again.
+		if _, err = c.GetCategoriesContext(ctx); err != nil {
+			return fmt.Errorf("logging in: %w", err)
+		}
+
+		status, err = c.postForm(ctx, path, values)
+	}
+
+	if err != nil {
+		return err
+	} else if status == http.StatusNotFound {
+		return fmt.Errorf("%w: %w: %s", ErrTorrentClient, errQbitNoPath, path)
+	} else if status != http.StatusOK {
+		return fmt.Errorf("%w: %s: %d %s", ErrTorrentClient, path, status, http.StatusText(status))
+	}
+
+	return nil
+}
+
+func (c *QbitConfig) postForm(ctx context.Context, path string, values url.Values) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+path, strings.NewReader(values.Encode()))
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if c.HTTPUser != "" || c.HTTPPass != "" {
+		req.SetBasicAuth(c.HTTPUser, c.HTTPPass)
+	}
+
+	resp, err := c.Config.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
+
+/* rTorrent. Hashes are upper case, and torrents are stopped and started instead of paused and resumed. */
+
+func (c *RtorrentConfig) torrents(_ context.Context) ([]*Torrent, error) {
+	results, err := c.Call("d.multicall2", "", string(rtorrent.ViewMain),
+		rtorrent.DHash.Query(),
+		rtorrent.DName.Query(),
+		"d.state=",
+		rtorrent.DIsActive.Query(),
+		rtorrent.DComplete.Query(),
+		rtorrent.DLabel.Query(),
+		rtorrent.DSizeInBytes.Query(),
+		rtorrent.DCompletedBytes.Query(),
+		"d.up.total=",
+		rtorrent.DDownRate.Query(),
+		rtorrent.DUpRate.Query(),
+		rtorrent.DRatio.Query(),
+		rtorrent.DDirectory.Query(),
+		"d.message=",
+		rtorrent.DCreationTime.Query(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("d.multicall2: %w", err)
+	}
+
+	torrents := []*Torrent{}
+	outer, _ := results.([]interface{})
+
+	for _, result := range outer {
+		inner, _ := result.([]interface{})
+		for _, data := range inner {
+			if fields, ok := data.([]interface{}); ok && len(fields) == 15 { //nolint:mnd // count of fields above.
+				torrents = append(torrents, rtorrentTorrent(fields))
+			}
+		}
+	}
+
+	return torrents, nil
+}
+
+// rtorrentTorrent converts the fields from d.multicall2 into a torrent.
+func rtorrentTorrent(fields []interface{}) *Torrent {
+	str := func(idx int) string { val, _ := fields[idx].(string); return val }
+	num := func(idx int) int64 { val, _ := fields[idx].(int); return int64(val) }
+	torrent := &Torrent{
+		Hash:       str(0),
+		Name:       str(1),
+		Category:   str(5),
+		Size:       num(6),
+		Downloaded: num(7),
+		Uploaded:   num(8),
+		DownRate:   num(9),
+		UpRate:     num(10),
+		Ratio:      float64(num(11)) / 1000, //nolint:mnd // rtorrent ratios are multiplied by 1000.
+		Path:       str(12),
+		Message:    str(13),
+		Added:      time.Unix(num(14), 0),
+		Paused:     num(2) == 0 || num(3) == 0,
+	}
+
+	if torrent.Size > 0 {
+		torrent.Progress = float64(torrent.Downloaded) * 100 / float64(torrent.Size) //nolint:mnd // percent.
+	}
+
+	switch {
+	case num(2) == 0:
+		torrent.State = "stopped"
+	case num(3) == 0:
+		torrent.State = "paused"
+	case num(4) == 1:
+		torrent.State = "seeding"
+	default:
+		torrent.State = "downloading"
+	}
+
+	return torrent
+}
+
+func (c *RtorrentConfig) action(_ context.Context, action string, hashes []string) error {
+	method := map[string]string{
+		torrentPause:   "d.stop",
+		torrentResume:  "d.start",
+		torrentRecheck: "d.check_hash",
+	}[action]
+
+	for _, hash := range hashes {
+		if _, err := c.Call(method, strings.ToUpper(hash)); err != nil {
+			return fmt.Errorf("%s: %s: %w", method, hash, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *RtorrentConfig) remove(_ context.Context, hashes []string, deleteData bool) error {
+	if deleteData {
+		return fmt.Errorf("%w: rTorrent cannot delete data", ErrNotSupported)
+	}
+
+	for _, hash := range hashes {
+		if _, err := c.Call("d.erase", strings.ToUpper(hash)); err != nil {
+			return fmt.Errorf("d.erase: %s: %w", hash, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *RtorrentConfig) category(_ context.Context, hashes []string, category string) error {
+	for _, hash := range hashes {
+		if _, err := c.Call("d.custom1.set", strings.ToUpper(hash), category); err != nil {
+			return fmt.Errorf("d.custom1.set: %s: %w", hash, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *RtorrentConfig) limits(_ context.Context, _ []string, _ *SpeedLimits) error {
+	return fmt.Errorf("%w: rTorrent has no per-torrent speed limits", ErrNotSupported)
+}
+
+/* Transmission. Labels require Transmission 3 (RPC v16) or newer. */
+
+func (c *XmissionConfig) torrents(ctx context.Context) ([]*Torrent, error) {
+	xfers, err := c.TorrentGetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting transfers: %w", err)
+	}
+
+	torrents := []*Torrent{}
+
+	for idx := range xfers {
+		torrents = append(torrents, xmissionTorrent(&xfers[idx]))
+	}
+
+	return torrents, nil
+}
+
+// xmissionTorrent converts a transmission torrent. Every field is a pointer, and may be nil.
+func xmissionTorrent(xfer *transmissionrpc.Torrent) *Torrent {
+	val := func(ptr *int64) int64 {
+		if ptr == nil {
+			return 0
+		}
+
+		return *ptr
+	}
+	torrent := &Torrent{
+		Downloaded: val(xfer.DownloadedEver),
+		Uploaded:   val(xfer.UploadedEver),
+		DownRate:   val(xfer.RateDownload),
+		UpRate:     val(xfer.RateUpload),
+	}
+
+	if len(xfer.Labels) > 0 {
+		torrent.Category = xfer.Labels[0]
+	}
+
+	if xfer.HashString != nil {
+		torrent.Hash = *xfer.HashString
+	}
+
+	if xfer.Name != nil {
+		torrent.Name = *xfer.Name
+	}
+
+	if xfer.Status != nil {
+		torrent.State = xfer.Status.String()
+		torrent.Paused = *xfer.Status == transmissionrpc.TorrentStatusStopped
+	}
+
+	if xfer.TotalSize != nil {
+		torrent.Size = int64(xfer.TotalSize.Byte())
+	}
+
+	if xfer.PercentDone != nil {
+		torrent.Progress = *xfer.PercentDone * 100 //nolint:mnd // convert to percent.
+	}
+
+	if xfer.UploadRatio != nil {
+		torrent.Ratio = *xfer.UploadRatio
+	}
+
+	if xfer.DownloadDir != nil {
+		torrent.Path = *xfer.DownloadDir
+	}
+
+	if xfer.ErrorString != nil {
+		torrent.Message = *xfer.ErrorString
+	}
+
+	if xfer.AddedDate != nil {
+		torrent.Added = *xfer.AddedDate
+	}
+
+	return torrent
+}
+
+func (c *XmissionConfig) action(ctx context.Context, action string, hashes []string) error {
+	var err error
+
+	switch hashes = lowerHashes(hashes); action {
+	case torrentPause:
+		err = c.TorrentStopHashes(ctx, hashes)
+	case torrentResume:
+		err = c.TorrentStartHashes(ctx, hashes)
+	case torrentRecheck:
+		err = c.TorrentVerifyHashes(ctx, hashes)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+
+	return nil
+}
+
+func (c *XmissionConfig) remove(ctx context.Context, hashes []string, deleteData bool) error {
+	ids, err := c.ids(ctx, hashes)
+	if err != nil {
+		return err
+	}
+
+	err = c.TorrentRemove(ctx, transmissionrpc.TorrentRemovePayload{IDs: ids, DeleteLocalData: deleteData})
+	if err != nil {
+		return fmt.Errorf("removing torrents: %w", err)
+	}
+
+	return nil
+}
+
+func (c *XmissionConfig) category(ctx context.Context, hashes []string, category string) error {
+	ids, err := c.ids(ctx, hashes)
+	if err != nil {
+		return err
+	}
+
+	labels := []string{}
+	if category != "" {
+		labels = append(labels, category)
+	}
+
+	if err = c.TorrentSet(ctx, transmissionrpc.TorrentSetPayload{IDs: ids, Labels: labels}); err != nil {
+		return fmt.Errorf("setting labels: %w", err)
+	}
+
+	return nil
+}
+
+func (c *XmissionConfig) limits(ctx context.Context, hashes []string, limits *SpeedLimits) error {
+	ids, err := c.ids(ctx, hashes)
+	if err != nil {
+		return err
+	}
+
+	download, upload := kilobytes(limits.Download, kilobyte), kilobytes(limits.Upload, kilobyte)
+	downLimited, upLimited := download > 0, upload > 0
+
+	err = c.TorrentSet(ctx, transmissionrpc.TorrentSetPayload{
+		IDs:             ids,
+		DownloadLimit:   &download,
+		DownloadLimited: &downLimited,
+		UploadLimit:     &upload,
+		UploadLimited:   &upLimited,
+	})
+	if err != nil {
+		return fmt.Errorf("setting limits: %w", err)
+	}
+
+	return nil
+}
+
+// ids returns the transmission torrent IDs for the hashes. Remove and set only accept IDs.
+func (c *XmissionConfig) ids(ctx context.Context, hashes []string) ([]int64, error) {
+	xfers, err := c.TorrentGetHashes(ctx, []string{"id"}, lowerHashes(hashes))
+	if err != nil {
+		return nil, fmt.Errorf("getting torrent IDs: %w", err)
+	}
+
+	ids := []int64{}
+
+	for _, xfer := range xfers {
+		if xfer.ID != nil {
+			ids = append(ids, *xfer.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrNoTorrent
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,293 @@
+package apps
+
+/* This file contains the API handlers shared by the torrent clients: Deluge, qBittorrent, rTorrent and Transmission. */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golift.io/starr"
+)
+
+// These are the torrent client app names. They are used in the API paths, ie. /api/qbit/1/torrents.
+const (
+	Deluge       starr.App = "Deluge"
+	Qbit         starr.App = "Qbit"
+	Rtorrent     starr.App = "Rtorrent"
+	Transmission starr.App = "Transmission"
+)
+
+// Errors returned by the torrent client handlers.
+var (
+	ErrNoDeluge       = fmt.Errorf("configured %s ID not found", Deluge)
+	ErrNoQbit         = fmt.Errorf("configured %s ID not found", Qbit)
+	ErrNoRtorrent     = fmt.Errorf("configured %s ID not found", Rtorrent)
+	ErrNoTransmission = fmt.Errorf("configured %s ID not found", Transmission)
+	ErrNotSupported   = errors.New("this action is not supported by the torrent client")
+	ErrNoTorrent      = errors.New("no torrents found with provided hashes")
+	ErrTorrentClient  = errors.New("torrent client request failed")
+)
+
+// These are the torrent actions that take no input besides the torrent hashes.
+const (
+	torrentPause   = "pause"
+	torrentResume  = "resume"
+	torrentRecheck = "recheck"
+)
+
+// Torrent is a torrent from any of the torrent clients, in a common format.
+type Torrent struct {
+	Hash  string `json:"hash"`
+	Name  string `json:"name"`
+	State string `json:"state"` // as reported by the client.
+	// Category is the qBittorrent category, the Deluge or rTorrent label, or the first Transmission label.
+	Category   string    `json:"category"`
+	Size       int64     `json:"size"`
+	Progress   float64   `json:"progress"` // percent.
+	Downloaded int64     `json:"downloaded"`
+	Uploaded   int64     `json:"uploaded"`
+	DownRate   int64     `json:"downRate"` // bytes per second.
+	UpRate     int64     `json:"upRate"`   // bytes per second.
+	Ratio      float64   `json:"ratio"`
+	Path       string    `json:"path"`
+	Paused     bool      `json:"paused"`
+	Message    string    `json:"message,omitempty"`
+	Added      time.Time `json:"added"`
+}
+
+// SpeedLimits are per-torrent speed limits in bytes per second. Zero removes the limit.
+type SpeedLimits struct {
+	Download int64 `json:"download"`
+	Upload   int64 `json:"upload"`
+}
+
+// torrentClient is implemented by each torrent client config.
+type torrentClient interface {
+	torrents(ctx context.Context) ([]*Torrent, error)
+	action(ctx context.Context, action string, hashes []string) error
+	remove(ctx context.Context, hashes []string, deleteData bool) error
+	category(ctx context.Context, hashes []string, category string) error
+	limits(ctx context.Context, hashes []string, limits *SpeedLimits) error
+}
+
+// torrentHandlers is called once on startup to register the web API paths for the torrent clients.
+func (a *Apps) torrentHandlers() {
+	for _, app := range []starr.App{Deluge, Qbit, Rtorrent, Transmission} {
+		a.HandleAPIpath(app, "/torrents", torrentList(app), "GET")
+		a.HandleAPIpath(app, "/torrents/{hashes}/{action:pause|resume|recheck}", torrentAction(app), "PUT")
+		a.HandleAPIpath(app, "/torrents/{hashes}", torrentRemove(app, false), "DELETE")
+		a.HandleAPIpath(app, "/torrents/{hashes}/data", torrentRemove(app, true), "DELETE")
+		a.HandleAPIpath(app, "/torrents/{hashes}/category", torrentCategory(app), "PUT")
+		a.HandleAPIpath(app, "/torrents/{hashes}/category/{category}", torrentCategory(app), "PUT")
+		a.HandleAPIpath(app, "/torrents/{hashes}/limits", torrentLimits(app), "PUT")
+	}
+}
+
+func getTorrentClient(req *http.Request, app starr.App) torrentClient {
+	return req.Context().Value(app).(torrentClient) //nolint:forcetypeassert
+}
+
+// torrentHashes returns the comma separated hashes from the request path.
+func torrentHashes(req *http.Request) []string {
+	hashes := []string{}
+
+	for _, hash := range strings.Split(mux.Vars(req)["hashes"], ",") {
+		if hash = strings.TrimSpace(hash); hash != "" {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	return hashes
+}
+
+// torrentError returns not implemented for unsupported actions, and the backup code for everything else.
+func torrentError(backupCode int, msg string, err error) (int, error) {
+	if errors.Is(err, ErrNotSupported) {
+		return http.StatusNotImplemented, fmt.Errorf("%s: %w", msg, err)
+	}
+
+	if errors.Is(err, ErrNoTorrent) {
+		return http.StatusNotFound, fmt.Errorf("%s: %w", msg, err)
+	}
+
+	return apiError(backupCode, msg, err)
+}
+
+// @Description  Returns the torrents in a Deluge, qBittorrent, rTorrent or Transmission instance.
+// @Description  Filter them with the state, category and search (name contains) query parameters.
+// @Description  A state of paused also matches every paused torrent, regardless of the client's state name.
+// @Summary      Retrieve torrents
+// @Tags         Torrents
+// @Produce      json
+// @Param        client    path   string  true  "torrent client: deluge, qbit, rtorrent, transmission"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        state     query  string  false "only return torrents in this state"
+// @Param        category  query  string  false "only return torrents with this category or label"
+// @Param        search    query  string  false "only return torrents with names that contain this"
+// @Success      200  {object} apps.Respond.apiResponse{message=[]Torrent} "list of torrents"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/{client}/{instance}/torrents [get]
+// @Security     ApiKeyAuth
+func torrentList(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		torrents, err := getTorrentClient(req, app).torrents(req.Context())
+		if err != nil {
+			return torrentError(http.StatusServiceUnavailable, "getting torrents", err)
+		}
+
+		query := req.URL.Query()
+		state, category := query.Get("state"), query.Get("category")
+		search := strings.ToLower(query.Get("search"))
+		output := []*Torrent{}
+
+		for _, torrent := range torrents {
+			switch {
+			case state != "" && !strings.EqualFold(state, torrent.State) && (state != torrentPause+"d" || !torrent.Paused):
+			case category != "" && !strings.EqualFold(category, torrent.Category):
+			case search != "" && !strings.Contains(strings.ToLower(torrent.Name), search):
+			default:
+				output = append(output, torrent)
+			}
+		}
+
+		return http.StatusOK, output
+	}
+}
+
+// @Description  Pauses, resumes or rechecks torrents. Provide a comma separated list of hashes.
+// @Description  rTorrent torrents are stopped and started.
+// @Summary      Pause, resume or recheck torrents
+// @Tags         Torrents
+// @Produce      json
+// @Param        client    path   string  true  "torrent client: deluge, qbit, rtorrent, transmission"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        hashes    path   string  true  "comma separated torrent hashes"
+// @Param        action    path   string  true  "pause, resume or recheck"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "torrent not found, or bad token or api key"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Router       /api/{client}/{instance}/torrents/{hashes}/{action} [put]
+// @Security     ApiKeyAuth
+func torrentAction(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		hashes, action := torrentHashes(req), mux.Vars(req)["action"]
+
+		err := getTorrentClient(req, app).action(req.Context(), action, hashes)
+		if err != nil {
+			return torrentError(http.StatusServiceUnavailable, action, err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("%s: %d torrent(s)", action, len(hashes))
+	}
+}
+
+// @Description  Removes torrents. Provide a comma separated list of hashes.
+// @Description  Use the /data path to delete the downloaded data too. rTorrent cannot delete data.
+// @Summary      Remove torrents
+// @Tags         Torrents
+// @Produce      json
+// @Param        client    path   string  true  "torrent client: deluge, qbit, rtorrent, transmission"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        hashes    path   string  true  "comma separated torrent hashes"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "torrent not found, or bad token or api key"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "deleting data is not supported"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Router       /api/{client}/{instance}/torrents/{hashes}/data [delete]
+// @Security     ApiKeyAuth
+func _() {}
+
+// @Description  Removes torrents, but not their data. Provide a comma separated list of hashes.
+// @Summary      Remove torrents
+// @Tags         Torrents
+// @Produce      json
+// @Param        client    path   string  true  "torrent client: deluge, qbit, rtorrent, transmission"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        hashes    path   string  true  "comma separated torrent hashes"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "torrent not found, or bad token or api key"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Router       /api/{client}/{instance}/torrents/{hashes} [delete]
+// @Security     ApiKeyAuth
+func torrentRemove(app starr.App, deleteData bool) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		hashes := torrentHashes(req)
+
+		err := getTorrentClient(req, app).remove(req.Context(), hashes, deleteData)
+		if err != nil {
+			return torrentError(http.StatusServiceUnavailable, "removing torrents", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("removed %d torrent(s), deleted data: %v", len(hashes), deleteData)
+	}
+}
+
+// @Description  Sets the category (qBittorrent) or label (Deluge, rTorrent, Transmission) for torrents.
+// @Description  Leave off the category to clear it. Deluge requires the label plugin.
+// @Summary      Set torrent category
+// @Tags         Torrents
+// @Produce      json
+// @Param        client    path   string  true  "torrent client: deluge, qbit, rtorrent, transmission"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        hashes    path   string  true  "comma separated torrent hashes"
+// @Param        category  path   string  false "new category or label"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "torrent not found, or bad token or api key"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Router       /api/{client}/{instance}/torrents/{hashes}/category/{category} [put]
+// @Security     ApiKeyAuth
+func torrentCategory(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		hashes, category := torrentHashes(req), mux.Vars(req)["category"]
+
+		err := getTorrentClient(req, app).category(req.Context(), hashes, category)
+		if err != nil {
+			return torrentError(http.StatusServiceUnavailable, "setting category", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("set category '%s' on %d torrent(s)", category, len(hashes))
+	}
+}
+
+// @Description  Sets download and upload speed limits for torrents, in bytes per second. Zero removes a limit.
+// @Description  Deluge and Transmission round the limits to kilobytes. rTorrent does not support this.
+// @Summary      Set torrent speed limits
+// @Tags         Torrents
+// @Produce      json
+// @Accept       json
+// @Param        client    path   string  true  "torrent client: deluge, qbit, transmission"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        hashes    path   string  true  "comma separated torrent hashes"
+// @Param        PUT body SpeedLimits true "speed limits"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "torrent not found, or bad token or api key"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "not supported"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Router       /api/{client}/{instance}/torrents/{hashes}/limits [put]
+// @Security     ApiKeyAuth
+func torrentLimits(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		var limits SpeedLimits
+		if err := json.NewDecoder(req.Body).Decode(&limits); err != nil {
+			return apiError(http.StatusBadRequest, "decoding payload", err)
+		}
+
+		hashes := torrentHashes(req)
+
+		err := getTorrentClient(req, app).limits(req.Context(), hashes, &limits)
+		if err != nil {
+			return torrentError(http.StatusServiceUnavailable, "setting limits", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("set limits on %d torrent(s), download: %d, upload: %d",
+			len(hashes), limits.Download, limits.Upload)
+	}
+}
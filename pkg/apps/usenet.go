@@ -0,0 +1,423 @@
+package apps
+
+/* This file contains the API handlers shared by the usenet clients: SABnzbd and NZBGet. */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps/apppkg/sabnzbd"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/gorilla/mux"
+	"golift.io/starr"
+)
+
+// These are the usenet client app names. They are used in the API paths, ie. /api/sabnzbd/1/queue/pause.
+const (
+	SABnzbd starr.App = "SABnzbd"
+	NZBGet  starr.App = "NZBGet"
+)
+
+// Errors returned by the usenet client handlers.
+var (
+	ErrNoSABnzbd    = fmt.Errorf("configured %s ID not found", SABnzbd)
+	ErrNoNZBGet     = fmt.Errorf("configured %s ID not found", NZBGet)
+	ErrInvalidJobID = errors.New("invalid job ID")
+	ErrUsenetClient = errors.New("usenet client request failed")
+)
+
+// These are the job priorities accepted by the API. Each client has its own values for them.
+const (
+	priorityLow    = "low"
+	priorityNormal = "normal"
+	priorityHigh   = "high"
+	priorityForce  = "force"
+)
+
+// usenetClient is implemented by each usenet client config.
+type usenetClient interface {
+	pauseQueue(ctx context.Context, pause bool) error
+	speedLimit(ctx context.Context, kbps int64) error
+	priority(ctx context.Context, ids []string, priority string) error
+	move(ctx context.Context, id string, position int) error
+	deleteJobs(ctx context.Context, ids []string) error
+	retry(ctx context.Context, ids []string) error
+	purgeHistory(ctx context.Context, failedOnly bool) error
+}
+
+// usenetHandlers is called once on startup to register the web API paths for the usenet clients.
+func (a *Apps) usenetHandlers() {
+	for _, app := range []starr.App{SABnzbd, NZBGet} {
+		a.HandleAPIpath(app, "/queue/{action:pause|resume}", usenetPause(app), "PUT")
+		a.HandleAPIpath(app, "/queue/speedlimit/{limit:[0-9]+}", usenetSpeedLimit(app), "PUT")
+		a.HandleAPIpath(app, "/queue/{ids}/priority/{priority:low|normal|high|force}", usenetPriority(app), "PUT")
+		a.HandleAPIpath(app, "/queue/{ids}/move/{position:[0-9]+}", usenetMove(app), "PUT")
+		a.HandleAPIpath(app, "/queue/{ids}", usenetDelete(app), "DELETE")
+		a.HandleAPIpath(app, "/history/{ids}/retry", usenetRetry(app), "PUT")
+		a.HandleAPIpath(app, "/history", usenetPurge(app), "DELETE")
+	}
+}
+
+func getUsenetClient(req *http.Request, app starr.App) usenetClient {
+	return req.Context().Value(app).(usenetClient) //nolint:forcetypeassert
+}
+
+// usenetIDs returns the comma separated job IDs from the request path.
+func usenetIDs(req *http.Request) []string {
+	ids := []string{}
+
+	for _, id := range strings.Split(mux.Vars(req)["ids"], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// usenetError returns bad request for invalid job IDs, and the backup code for everything else.
+func usenetError(backupCode int, msg string, err error) (int, error) {
+	if errors.Is(err, ErrInvalidJobID) {
+		return http.StatusBadRequest, fmt.Errorf("%s: %w", msg, err)
+	}
+
+	return apiError(backupCode, msg, err)
+}
+
+// @Description  Pauses or resumes the download queue in SABnzbd or NZBGet.
+// @Summary      Pause or resume the usenet queue
+// @Tags         Usenet
+// @Produce      json
+// @Param        client    path   string  true  "usenet client: sabnzbd, nzbget"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        action    path   string  true  "pause or resume"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/{client}/{instance}/queue/{action} [put]
+// @Security     ApiKeyAuth
+func usenetPause(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		action := mux.Vars(req)["action"]
+
+		if err := getUsenetClient(req, app).pauseQueue(req.Context(), action == "pause"); err != nil {
+			return usenetError(http.StatusServiceUnavailable, action, err)
+		}
+
+		return http.StatusOK, action + ": ok"
+	}
+}
+
+// @Description  Sets the download speed limit in SABnzbd or NZBGet, in kilobytes per second. Zero removes the limit.
+// @Summary      Set usenet speed limit
+// @Tags         Usenet
+// @Produce      json
+// @Param        client    path   string  true  "usenet client: sabnzbd, nzbget"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        limit     path   int64   true  "speed limit in KB/s"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/{client}/{instance}/queue/speedlimit/{limit} [put]
+// @Security     ApiKeyAuth
+func usenetSpeedLimit(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		limit, _ := strconv.ParseInt(mux.Vars(req)["limit"], mnd.Base10, mnd.Bits64)
+
+		if err := getUsenetClient(req, app).speedLimit(req.Context(), limit); err != nil {
+			return usenetError(http.StatusServiceUnavailable, "setting speed limit", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("speed limit set to %d KB/s", limit)
+	}
+}
+
+// @Description  Sets the priority of jobs in the SABnzbd or NZBGet queue. Provide a comma separated list of job IDs.
+// @Summary      Set usenet job priority
+// @Tags         Usenet
+// @Produce      json
+// @Param        client    path   string  true  "usenet client: sabnzbd, nzbget"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        ids       path   string  true  "comma separated job IDs: nzo_id for SABnzbd, NZBID for NZBGet"
+// @Param        priority  path   string  true  "low, normal, high or force"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "invalid job ID"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/{client}/{instance}/queue/{ids}/priority/{priority} [put]
+// @Security     ApiKeyAuth
+func usenetPriority(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		ids, priority := usenetIDs(req), mux.Vars(req)["priority"]
+
+		if err := getUsenetClient(req, app).priority(req.Context(), ids, priority); err != nil {
+			return usenetError(http.StatusServiceUnavailable, "setting priority", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("set priority '%s' on %d job(s)", priority, len(ids))
+	}
+}
+
+// @Description  Moves a job to a position in the SABnzbd or NZBGet queue. The first position is 0.
+// @Summary      Move usenet job
+// @Tags         Usenet
+// @Produce      json
+// @Param        client    path   string  true  "usenet client: sabnzbd, nzbget"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        ids       path   string  true  "job ID: nzo_id for SABnzbd, NZBID for NZBGet"
+// @Param        position  path   int64   true  "new queue position"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "invalid job ID"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/{client}/{instance}/queue/{ids}/move/{position} [put]
+// @Security     ApiKeyAuth
+func usenetMove(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		ids := usenetIDs(req)
+		if len(ids) != 1 {
+			return http.StatusBadRequest, fmt.Errorf("%w: provide one job ID to move", ErrInvalidJobID)
+		}
+
+		position, _ := strconv.Atoi(mux.Vars(req)["position"])
+
+		if err := getUsenetClient(req, app).move(req.Context(), ids[0], position); err != nil {
+			return usenetError(http.StatusServiceUnavailable, "moving job", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("moved job %s to position %d", ids[0], position)
+	}
+}
+
+// @Description  Deletes jobs, and their downloaded files, from the SABnzbd or NZBGet queue.
+// @Description  Provide a comma separated list of job IDs.
+// @Summary      Delete usenet jobs
+// @Tags         Usenet
+// @Produce      json
+// @Param        client    path   string  true  "usenet client: sabnzbd, nzbget"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        ids       path   string  true  "comma separated job IDs: nzo_id for SABnzbd, NZBID for NZBGet"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "invalid job ID"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/{client}/{instance}/queue/{ids} [delete]
+// @Security     ApiKeyAuth
+func usenetDelete(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		ids := usenetIDs(req)
+
+		if err := getUsenetClient(req, app).deleteJobs(req.Context(), ids); err != nil {
+			return usenetError(http.StatusServiceUnavailable, "deleting jobs", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("deleted %d job(s)", len(ids))
+	}
+}
+
+// @Description  Retries failed jobs in the SABnzbd or NZBGet history. Provide a comma separated list of job IDs.
+// @Summary      Retry failed usenet jobs
+// @Tags         Usenet
+// @Produce      json
+// @Param        client    path   string  true  "usenet client: sabnzbd, nzbget"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        ids       path   string  true  "comma separated job IDs: nzo_id for SABnzbd, NZBID for NZBGet"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "invalid job ID"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/{client}/{instance}/history/{ids}/retry [put]
+// @Security     ApiKeyAuth
+func usenetRetry(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		ids := usenetIDs(req)
+
+		if err := getUsenetClient(req, app).retry(req.Context(), ids); err != nil {
+			return usenetError(http.StatusServiceUnavailable, "retrying jobs", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("retried %d job(s)", len(ids))
+	}
+}
+
+// @Description  Deletes every job from the SABnzbd or NZBGet history. Files are not deleted.
+// @Description  Set the failed query parameter to true to only delete failed jobs.
+// @Summary      Purge usenet history
+// @Tags         Usenet
+// @Produce      json
+// @Param        client    path   string  true  "usenet client: sabnzbd, nzbget"
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        failed    query  bool    false "only delete failed jobs"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "ok"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "instance error"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/{client}/{instance}/history [delete]
+// @Security     ApiKeyAuth
+func usenetPurge(app starr.App) APIHandler {
+	return func(req *http.Request) (int, interface{}) {
+		failedOnly, _ := strconv.ParseBool(req.URL.Query().Get("failed"))
+
+		if err := getUsenetClient(req, app).purgeHistory(req.Context(), failedOnly); err != nil {
+			return usenetError(http.StatusServiceUnavailable, "purging history", err)
+		}
+
+		return http.StatusOK, fmt.Sprintf("purged history, failed only: %v", failedOnly)
+	}
+}
+
+/* SABnzbd. */
+
+func (c *SabNZBConfig) pauseQueue(ctx context.Context, pause bool) error {
+	if pause {
+		return c.PauseQueue(ctx) //nolint:wrapcheck
+	}
+
+	return c.ResumeQueue(ctx) //nolint:wrapcheck
+}
+
+func (c *SabNZBConfig) speedLimit(ctx context.Context, kbps int64) error {
+	return c.SetSpeedLimit(ctx, kbps) //nolint:wrapcheck
+}
+
+func (c *SabNZBConfig) priority(ctx context.Context, ids []string, priority string) error {
+	value := map[string]sabnzbd.Priority{
+		priorityLow:    sabnzbd.PriorityLow,
+		priorityNormal: sabnzbd.PriorityNormal,
+		priorityHigh:   sabnzbd.PriorityHigh,
+		priorityForce:  sabnzbd.PriorityForce,
+	}[priority]
+
+	for _, id := range ids {
+		if _, err := c.SetPriority(ctx, id, value); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *SabNZBConfig) move(ctx context.Context, id string, position int) error {
+	return c.MoveJob(ctx, id, position) //nolint:wrapcheck
+}
+
+func (c *SabNZBConfig) deleteJobs(ctx context.Context, ids []string) error {
+	return c.DeleteJobs(ctx, true, ids...) //nolint:wrapcheck
+}
+
+func (c *SabNZBConfig) retry(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := c.RetryJob(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *SabNZBConfig) purgeHistory(ctx context.Context, failedOnly bool) error {
+	return c.PurgeHistory(ctx, failedOnly, false) //nolint:wrapcheck
+}
+
+/* NZBGet. Job IDs are the NZBID numbers. */
+
+func (c *NZBGetConfig) pauseQueue(ctx context.Context, pause bool) error {
+	var (
+		ok  bool
+		err error
+	)
+
+	if pause {
+		ok, err = c.PauseDownloadContext(ctx)
+	} else {
+		ok, err = c.ResumeDownloadContext(ctx)
+	}
+
+	return nzbgetError("pause", ok, err)
+}
+
+func (c *NZBGetConfig) speedLimit(ctx context.Context, kbps int64) error {
+	ok, err := c.RateContext(ctx, kbps)
+	return nzbgetError("rate", ok, err)
+}
+
+func (c *NZBGetConfig) priority(ctx context.Context, ids []string, priority string) error {
+	value := map[string]string{
+		priorityLow:    "-50",
+		priorityNormal: "0",
+		priorityHigh:   "50",
+		priorityForce:  "900",
+	}[priority]
+
+	return c.edit(ctx, "GroupSetPriority", value, ids)
+}
+
+func (c *NZBGetConfig) move(ctx context.Context, id string, position int) error {
+	// NZBGet only moves jobs relative to where they are, so move it to the top first.
+	if err := c.edit(ctx, "GroupMoveTop", "", []string{id}); err != nil || position == 0 {
+		return err
+	}
+
+	return c.edit(ctx, "GroupMoveOffset", strconv.Itoa(position), []string{id})
+}
+
+func (c *NZBGetConfig) deleteJobs(ctx context.Context, ids []string) error {
+	return c.edit(ctx, "GroupFinalDelete", "", ids)
+}
+
+func (c *NZBGetConfig) retry(ctx context.Context, ids []string) error {
+	return c.edit(ctx, "HistoryRetryFailed", "", ids)
+}
+
+func (c *NZBGetConfig) purgeHistory(ctx context.Context, failedOnly bool) error {
+	history, err := c.HistoryContext(ctx, false)
+	if err != nil {
+		return fmt.Errorf("getting history: %w", err)
+	}
+
+	ids := []int64{}
+
+	for _, item := range history {
+		if !failedOnly || strings.HasPrefix(item.Status, "FAILURE") {
+			ids = append(ids, item.NZBID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ok, err := c.EditQueueContext(ctx, "HistoryFinalDelete", "", ids)
+
+	return nzbgetError("HistoryFinalDelete", ok, err)
+}
+
+// edit runs an editqueue command on the job IDs.
+func (c *NZBGetConfig) edit(ctx context.Context, command, param string, ids []string) error {
+	nzbIDs := make([]int64, len(ids))
+
+	for idx, id := range ids {
+		var err error
+		if nzbIDs[idx], err = strconv.ParseInt(id, mnd.Base10, mnd.Bits64); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidJobID, id)
+		}
+	}
+
+	ok, err := c.EditQueueContext(ctx, command, param, nzbIDs)
+
+	return nzbgetError(command, ok, err)
+}
+
+// nzbgetError wraps an error from NZBGet, or creates one if the command returned false.
+func nzbgetError(command string, ok bool, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	} else if !ok {
+		return fmt.Errorf("%w: %s returned false", ErrUsenetClient, command)
+	}
+
+	return nil
+}
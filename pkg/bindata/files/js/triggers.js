@@ -19,6 +19,57 @@ function triggerAction(action)
     });
 }
 
+// getBackupList loads the known-good backups that can be restored.
+function getBackupList()
+{
+    $.ajax({
+        url: URLBase+'ajax/backups',
+        success: function (data){
+            $('#backupList').html(data);
+        },
+        error: function (response, status, error) {
+            if (response.status == 0) {
+                toast('Web Server Error',
+                    'Notifiarr client appears to be down! Hard refresh recommended.', 'error', 20000);
+            } else {
+                toast(error!=''?error:'Bad Request', response.responseText, 'error', 15000);
+            }
+        }
+    });
+}
+
+// restoreBackup validates a backup, and restores or stages it after confirmation.
+function restoreBackup(app, instance, key, mode)
+{
+    const dir = $('#restoreDir').val();
+    if (mode == 'upload' && !confirm('Upload '+key+' to '+app+' '+instance+'? The app restarts and its current database is replaced.')) {
+        return;
+    } else if (mode == 'stage' && (dir == '' || !confirm('Stage '+key+' in a new folder inside '+dir+'?'))) {
+        if (dir == '') {
+            toast('Restore Error', 'Enter a Stage Folder first.', 'error', 10000);
+        }
+        return;
+    }
+
+    toast('Working', 'Validating backup! This may take a minute.', 'success', 5000);
+    $.ajax({
+        type: 'POST',
+        url: URLBase+'restoreBackup/'+app+'/'+instance,
+        data: {key: key, mode: mode, dir: dir},
+        success: function (data){
+            toast('Backup Restore', data, 'success', 15000);
+        },
+        error: function (response, status, error) {
+            if (response.status == 0) {
+                toast('Web Server Error',
+                    'Notifiarr client appears to be down! Hard refresh recommended.', 'error', 30000);
+            } else {
+                toast('Restore Error', error+': '+response.responseText, 'error', 20000);
+            }
+        }
+    });
+}
+
 function testRegex()
 {
     let fields = '';
@@ -113,3 +164,57 @@ function runCommand(from, hash)
     from.parents('.ui-dialog').find('.ui-dialog-content').dialog('close');
     $('#commandArgs'+hash).html('');
 }
+
+// streamCommand runs a command and displays its output in a dialog as it's written.
+function streamCommand(from, hash)
+{
+    if (!('WebSocket' in window)) {
+        toast('Websocket Error', 'Your browser does not support websockets, output streaming not available.', 'error');
+        return
+    }
+
+    let fields = '';
+    from.closest('table').find('#args').each(function() {
+        fields += '&' + $(this).serialize();
+    });
+    from.parents('.ui-dialog').find('.ui-dialog-content').dialog('close');
+    $('#commandArgs'+hash).html('');
+
+    const box = $('<pre style="max-height:500px;overflow:auto;white-space:pre-wrap;"></pre>');
+    const socket = new WebSocket(location.origin.replace(/^http/, 'ws') + URLBase +'ws?source=command&hash='+ hash + fields);
+
+    box.dialog({
+        title: 'Command Output',
+        modal: true,
+        width: 'auto',
+        resizable: true,
+        dialogClass: 'modal-body',
+        buttons: {
+            'Cancel Command': function() {
+                socket.send('cancel');
+            },
+        },
+        close: function (event, ui) {
+            socket.close();
+            $(this).dialog('destroy').remove();
+        }
+    });
+
+    socket.onmessage = function(incoming) {
+        const msg = JSON.parse(incoming.data);
+        if (msg.done) {
+            const status = 'exit code: '+ msg.exit +', elapsed: '+ msg.elapsed + (msg.error ? ', error: '+ msg.error : '');
+            box.append($('<b/>').text('\n'+ status));
+            box.parent().find('.ui-dialog-buttonpane button').prop('disabled', true);
+            toast('Command Finished', status, msg.error ? 'error' : 'success');
+        } else {
+            box.append($('<div/>').text(msg.line));
+        }
+        box.scrollTop(box.prop('scrollHeight'));
+    };
+
+    socket.onerror = function(data) {
+        toast('Websocket Error', 'Error connecting to the client websocket, details in console.', 'error');
+        console.log(data);
+    };
+}
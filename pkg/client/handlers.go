@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/CAFxX/httpcompression"
 	"github.com/Notifiarr/notifiarr/pkg/bindata"
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/gorilla/mux"
 	"golift.io/starr"
 )
@@ -90,6 +92,9 @@ func (c *Client) httpGuiHandlers(base string, compress func(handler http.Handler
 	gui.HandleFunc("/browse", c.handleFileBrowser).Queries("dir", "{dir}").Methods("GET")
 	gui.HandleFunc("/ajax/{path:cmdstats|cmdargs}/{hash}", c.handleCommandStats).Methods("GET")
 	gui.HandleFunc("/runCommand/{hash}", c.handleRunCommand).Methods("POST")
+	gui.HandleFunc("/ajax/backups", c.handleBackupList).Methods("GET")
+	gui.HandleFunc("/restoreBackup/{app:[a-z]+}/{instance:[0-9]+}", c.triggers.Backups.RestoreGUIHandler).Methods("POST")
+	gui.HandleFunc("/ws", c.handleCommandSocket).Queries("source", "command", "hash", "{hash}").Methods("GET")
 	gui.HandleFunc("/ws", c.handleWebSockets).Queries("source", "{source}", "fileId", "{fileId}").Methods("GET")
 	gui.HandleFunc("/docs/json/{instance}", c.handlerSwaggerDoc).Methods("GET")
 	gui.HandleFunc("/ui.json", c.handlerSwaggerDoc).Methods("GET")
@@ -107,10 +112,34 @@ func (c *Client) httpAPIHandlers() {
 	c.Config.HandleAPIpath("", "trigger/{trigger:[0-9a-z-]+}/{content}", c.triggers.APIHandler, "GET", "POST")
 	c.Config.HandleAPIpath("", "services/{action}", c.Config.Services.APIHandler, "GET")
 	c.Config.HandleAPIpath("", "triggers", c.triggers.HandleGetTriggers, "GET")
+	c.Config.HandleAPIpath("", "commands/history", c.triggers.Commands.HistoryHandler, "GET")
+	c.Config.HandleAPIpath("", "commands/history/{hash}", c.triggers.Commands.HistoryHandler, "GET")
+	c.Config.HandleAPIpath("", "queues/history", c.triggers.StarrQueue.HistoryHandler, "GET")
+	c.Config.HandleAPIpath("", "queues/history/{app:[a-z]+}/{instance:[0-9]+}",
+		c.triggers.StarrQueue.HistoryHandler, "GET")
+	c.Config.HandleAPIpath("", "dashboard", c.triggers.Dashboard.Handler, "GET")
+	c.Config.HandleAPIpath("", "dashboard/{app:[a-z]+}", c.triggers.Dashboard.Handler, "GET")
+	c.Config.HandleAPIpath("", "dashboard/{app:[a-z]+}/{instance:[0-9]+}", c.triggers.Dashboard.Handler, "GET")
+	c.Config.HandleAPIpath("", "backups", c.triggers.Backups.ListHandler, "GET")
+	c.Config.HandleAPIpath("", "backups/{app:[a-z]+}", c.triggers.Backups.ListHandler, "GET")
+	c.Config.HandleAPIpath("", "backups/{app:[a-z]+}/{instance:[0-9]+}", c.triggers.Backups.ListHandler, "GET")
+	c.Config.HandleAPIpath("", "backups/{app:[a-z]+}/{instance:[0-9]+}/restore",
+		c.triggers.Backups.RestoreHandler, "POST")
+	c.Config.HandleAPIpath("", "data", data.Handler, "GET")
+	c.Config.HandleAPIpath("", "data/{key}", data.Handler, "GET")
 	c.Config.HandleAPIpath("", "ping", c.handleInstancePing, "GET")
 	c.Config.HandleAPIpath("", "ping/{app:[a-z,]+}", c.handleInstancePing, "GET")
 	c.Config.HandleAPIpath("", "ping/{app:[a-z]+}/{instance:[0-9]+}", c.handleInstancePing, "GET")
 
+	// Calendar apps cannot send headers, so the feed also accepts the API key in a query parameter, like Plex does.
+	calendar := path.Join(c.Config.URLBase, "api", "calendar.ics")
+	if c.Config.Apps.APIKey != "" {
+		c.Config.Router.HandleFunc(calendar, c.triggers.Calendar.Handler).Methods("GET").
+			Queries("apikey", "{apikey:"+regexp.QuoteMeta(c.Config.Apps.APIKey)+"}")
+	}
+
+	c.Config.Router.Handle(calendar, c.Config.CheckAPIKey(http.HandlerFunc(c.triggers.Calendar.Handler))).Methods("GET")
+
 	// Aggregate handlers. Non-app specific.
 	c.Config.HandleAPIpath("", "/trash/{app}", c.triggers.CFSync.Handler, "POST")
 
@@ -119,6 +148,7 @@ func (c *Client) httpAPIHandlers() {
 		c.Config.HandleAPIpath(starr.Plex, "directory", c.Config.Plex.HandleDirectory, "GET")
 		c.Config.HandleAPIpath(starr.Plex, "emptytrash/{key}", c.Config.Plex.HandleEmptyTrash, "GET")
 		c.Config.HandleAPIpath(starr.Plex, "markwatched/{key}", c.Config.Plex.HandleMarkWatched, "GET")
+		c.Config.HandleAPIpath(starr.Plex, "cooldowns", c.handlePlexCooldowns, "GET")
 		c.Config.HandleAPIpath(starr.Plex, "kill", c.Config.Plex.HandleKillSession, "GET").
 			Queries("reason", "{reason:.*}", "sessionId", "{sessionId:.*}")
 
@@ -500,6 +500,19 @@ func (c *Client) handleCommandStats(response http.ResponseWriter, request *http.
 	}
 }
 
+// handleBackupList renders the known-good starr backups that can be restored.
+func (c *Client) handleBackupList(response http.ResponseWriter, request *http.Request) {
+	backups, err := c.triggers.Backups.Restorable(request.Context(), "", 0)
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.template.ExecuteTemplate(response, "ajax/backups.html", backups); err != nil {
+		http.Error(response, "template error: "+err.Error(), http.StatusOK)
+	}
+}
+
 // handleRunCommand only handles commands with arguments.
 // Commands without arguments are handled as an instance test.
 func (c *Client) handleRunCommand(response http.ResponseWriter, request *http.Request) {
@@ -511,6 +524,11 @@ func (c *Client) handleRunCommand(response http.ResponseWriter, request *http.Re
 
 	_ = request.ParseForm()
 
+	if err := cmd.ValidateArgs(request.PostForm["args"]); err != nil {
+		http.Error(response, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
 	cmd.Run(&common.ActionInput{
 		Type: website.EventGUI,
 		Args: request.PostForm["args"],
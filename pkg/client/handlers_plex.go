@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/apps"
 	"github.com/Notifiarr/notifiarr/pkg/apps/apppkg/plex"
+	"github.com/Notifiarr/notifiarr/pkg/cooldown"
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
@@ -116,3 +118,32 @@ func (c *Client) plexCooldown() time.Duration {
 
 	return time.Minute
 }
+
+// cooldownFile is saved next to the config file, and contains the active plex webhook cooldowns.
+const cooldownFile = "notifiarr.cooldowns.json"
+
+// persistPlexTimer saves the plex webhook cooldowns next to the config file, so they survive restarts.
+// The timer lives for the life of the app, so reloads do not reset it.
+func (c *Client) persistPlexTimer() {
+	if c.Flags.ConfigFile == "" {
+		return
+	}
+
+	store := cooldown.FileStore(filepath.Join(filepath.Dir(c.Flags.ConfigFile), cooldownFile))
+	if err := c.plexTimer.Persist(store); err != nil {
+		c.Errorf("Plex webhook cooldowns: %v", err)
+	}
+}
+
+// @Description  Returns the active Plex webhook cooldown timers, and how long until each one expires.
+// @Description  Durations are in nanoseconds. Webhooks for these keys are ignored until they expire.
+// @Summary      Retrieve active Plex webhook cooldowns.
+// @Tags         Plex
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=[]cooldown.Cooldown} "active cooldowns"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/plex/cooldowns [get]
+// @Security     ApiKeyAuth
+func (c *Client) handlePlexCooldowns(_ *http.Request) (int, interface{}) {
+	return http.StatusOK, c.plexTimer.List()
+}
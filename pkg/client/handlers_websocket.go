@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,6 +10,9 @@ import (
 
 	"github.com/Notifiarr/notifiarr/pkg/logs"
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/commands"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/nxadm/tail"
@@ -159,3 +164,108 @@ func (c *Client) webSocketReader(socket *websocket.Conn) {
 		}
 	}
 }
+
+// commandSocketMsg is written to the websocket for each line of command output, and once more when it finishes.
+type commandSocketMsg struct {
+	Line    string `json:"line,omitempty"`
+	Done    bool   `json:"done,omitempty"`
+	Exit    int    `json:"exit"`
+	Error   string `json:"error,omitempty"`
+	Elapsed string `json:"elapsed,omitempty"`
+}
+
+// handleCommandSocket runs a command and streams its output to a websocket, line by line.
+// Sending "cancel" on the websocket kills the running command.
+func (c *Client) handleCommandSocket(response http.ResponseWriter, request *http.Request) {
+	defer c.CapturePanic()
+
+	cmd := c.triggers.Commands.GetByHash(mux.Vars(request)["hash"])
+	if cmd == nil {
+		http.Error(response, "Invalid command Hash provided", http.StatusBadRequest)
+		c.socketLog(http.StatusBadRequest, request)
+
+		return
+	}
+
+	socket, err := upgrader.Upgrade(response, request, nil)
+	if err != nil {
+		c.Errorf("[gui requested] Creating Websocket: %v", err)
+		c.socketLog(http.StatusInternalServerError, request)
+
+		return
+	}
+	defer socket.Close()
+
+	c.socketLog(http.StatusOK, request)
+
+	// The request context is useless after the upgrade, and the command has its own timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go c.commandSocketReader(socket, cancel)
+	go c.commandSocketPinger(ctx, socket)
+
+	start := time.Now()
+	input := &common.ActionInput{Type: website.EventGUI, Args: request.URL.Query()["args"]}
+	_, err = cmd.Stream(ctx, input, func(line string) {
+		c.commandSocketWrite(socket, &commandSocketMsg{Line: line})
+	})
+
+	done := &commandSocketMsg{
+		Done:    true,
+		Exit:    commands.ExitCode(err),
+		Elapsed: time.Since(start).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		done.Error = err.Error()
+	}
+
+	c.commandSocketWrite(socket, done)
+	_ = socket.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
+
+func (c *Client) commandSocketWrite(socket *websocket.Conn, msg *commandSocketMsg) {
+	data, _ := json.Marshal(msg)
+	_ = socket.SetWriteDeadline(time.Now().Add(10 * time.Second)) //nolint:mnd
+
+	if err := socket.WriteMessage(websocket.TextMessage, data); err != nil {
+		c.Debugf("command websocket write error: %v", err)
+	}
+}
+
+// commandSocketReader cancels the running command when the browser asks for it.
+func (c *Client) commandSocketReader(socket *websocket.Conn, cancel context.CancelFunc) {
+	defer c.CapturePanic()
+
+	socket.SetReadLimit(mnd.Kilobyte)
+
+	for {
+		_, msg, err := socket.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if string(msg) == "cancel" {
+			c.Printf("[gui requested] Cancelling running command via websocket.")
+			cancel()
+		}
+	}
+}
+
+// commandSocketPinger keeps the websocket alive while a command runs without output.
+func (c *Client) commandSocketPinger(ctx context.Context, socket *websocket.Conn) {
+	ticker := time.NewTicker(29 * time.Second) //nolint:mnd
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := socket.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -176,6 +176,8 @@ func (c *Client) start(ctx context.Context, msgs []string, newPassword string) e
 		return err
 	}
 
+	c.persistPlexTimer()
+
 	clientInfo := c.configureServices(ctx)
 
 	if newPassword != "" {
@@ -400,6 +402,11 @@ func (c *Client) stop(ctx context.Context, event website.EventType) error {
 		c.triggers.Stop(event)
 		c.Config.Services.Stop()
 		c.Config.Stop()
+
+		if err := c.plexTimer.Save(); err != nil {
+			c.Errorf("Plex webhook cooldowns: %v", err)
+		}
+
 		c.Print("==> All systems powered down!")
 	}()
 
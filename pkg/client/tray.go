@@ -358,9 +358,14 @@ func (c *Client) buildDynamicTimerMenus() {
 	menu["timerinfo"].Disable()
 
 	for idx, timer := range timers {
-		desc := fmt.Sprintf("%s; config: interval: %s, path: %s", timer.Desc, timer.Interval, timer.URI)
+		interval := "interval: " + timer.Interval.String()
+		if schedule := timer.Schedule(); schedule != "" {
+			interval = "schedule: " + schedule
+		}
+
+		desc := fmt.Sprintf("%s; config: %s, path: %s", timer.Desc, interval, timer.URI)
 		if timer.Desc == "" {
-			desc = fmt.Sprintf("dynamic custom timer; config: interval: %s, path: %s", timer.Interval, timer.URI)
+			desc = fmt.Sprintf("dynamic custom timer; config: %s, path: %s", interval, timer.URI)
 		}
 
 		name := timerPrefix + timer.Name
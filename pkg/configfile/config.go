@@ -25,8 +25,12 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/services"
 	"github.com/Notifiarr/notifiarr/pkg/snapshot"
 	"github.com/Notifiarr/notifiarr/pkg/triggers"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/backups"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/commands"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/filewatch"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/starrqueue"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/workflow"
 	"github.com/Notifiarr/notifiarr/pkg/ui"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
@@ -50,22 +54,28 @@ const (
 
 // Config represents the data in our config file.
 type Config struct {
-	HostID     string                 `json:"hostId"      toml:"host_id"       xml:"host_id"       yaml:"hostId"`
-	UIPassword CryptPass              `json:"uiPassword"  toml:"ui_password"   xml:"ui_password"   yaml:"uiPassword"`
-	BindAddr   string                 `json:"bindAddr"    toml:"bind_addr"     xml:"bind_addr"     yaml:"bindAddr"`
-	SSLCrtFile string                 `json:"sslCertFile" toml:"ssl_cert_file" xml:"ssl_cert_file" yaml:"sslCertFile"`
-	SSLKeyFile string                 `json:"sslKeyFile"  toml:"ssl_key_file"  xml:"ssl_key_file"  yaml:"sslKeyFile"`
-	Upstreams  []string               `json:"upstreams"   toml:"upstreams"     xml:"upstreams"     yaml:"upstreams"`
-	AutoUpdate string                 `json:"autoUpdate"  toml:"auto_update"   xml:"auto_update"   yaml:"autoUpdate"`
-	UnstableCh bool                   `json:"unstableCh"  toml:"unstable_ch"   xml:"unstable_ch"   yaml:"unstableCh"`
-	Timeout    cnfg.Duration          `json:"timeout"     toml:"timeout"       xml:"timeout"       yaml:"timeout"`
-	Retries    int                    `json:"retries"     toml:"retries"       xml:"retries"       yaml:"retries"`
-	Snapshot   *snapshot.Config       `json:"snapshot"    toml:"snapshot"      xml:"snapshot"      yaml:"snapshot"`
-	Services   *services.Config       `json:"services"    toml:"services"      xml:"services"      yaml:"services"`
-	Service    []*services.Service    `json:"service"     toml:"service"       xml:"service"       yaml:"service"`
-	EnableApt  bool                   `json:"apt"         toml:"apt"           xml:"apt"           yaml:"apt"`
-	WatchFiles []*filewatch.WatchFile `json:"watchFiles"  toml:"watch_file"    xml:"watch_file"    yaml:"watchFiles"`
-	Commands   []*commands.Command    `json:"commands"    toml:"command"       xml:"command"       yaml:"commands"`
+	HostID     string                 `json:"hostId"      toml:"host_id"        xml:"host_id"        yaml:"hostId"`
+	UIPassword CryptPass              `json:"uiPassword"  toml:"ui_password"    xml:"ui_password"    yaml:"uiPassword"`
+	BindAddr   string                 `json:"bindAddr"    toml:"bind_addr"      xml:"bind_addr"      yaml:"bindAddr"`
+	SSLCrtFile string                 `json:"sslCertFile" toml:"ssl_cert_file"  xml:"ssl_cert_file"  yaml:"sslCertFile"`
+	SSLKeyFile string                 `json:"sslKeyFile"  toml:"ssl_key_file"   xml:"ssl_key_file"   yaml:"sslKeyFile"`
+	Upstreams  []string               `json:"upstreams"   toml:"upstreams"      xml:"upstreams"      yaml:"upstreams"`
+	AutoUpdate string                 `json:"autoUpdate"  toml:"auto_update"    xml:"auto_update"    yaml:"autoUpdate"`
+	UnstableCh bool                   `json:"unstableCh"  toml:"unstable_ch"    xml:"unstable_ch"    yaml:"unstableCh"`
+	Timeout    cnfg.Duration          `json:"timeout"     toml:"timeout"        xml:"timeout"        yaml:"timeout"`
+	Retries    int                    `json:"retries"     toml:"retries"        xml:"retries"        yaml:"retries"`
+	Snapshot   *snapshot.Config       `json:"snapshot"    toml:"snapshot"       xml:"snapshot"       yaml:"snapshot"`
+	Services   *services.Config       `json:"services"    toml:"services"       xml:"services"       yaml:"services"`
+	Service    []*services.Service    `json:"service"     toml:"service"        xml:"service"        yaml:"service"`
+	EnableApt  bool                   `json:"apt"         toml:"apt"            xml:"apt"            yaml:"apt"`
+	WatchFiles []*filewatch.WatchFile `json:"watchFiles"  toml:"watch_file"     xml:"watch_file"     yaml:"watchFiles"`
+	Commands   []*commands.Command    `json:"commands"    toml:"command"        xml:"command"        yaml:"commands"`
+	CmdQueue   *commands.QueueConfig  `json:"cmdQueue"    toml:"command_queue"  xml:"command_queue"  yaml:"cmdQueue"`
+	DataStore  *data.Config           `json:"dataStore"   toml:"data_store"     xml:"data_store"     yaml:"dataStore"`
+	StuckItems *starrqueue.Config     `json:"stuckItems"  toml:"stuck_items"    xml:"stuck_items"    yaml:"stuckItems"`
+	Archive    *backups.ArchiveConfig `json:"archive"     toml:"backup_archive" xml:"backup_archive" yaml:"archive"`
+	BackupDB   *backups.CheckConfig   `json:"backupDB"    toml:"backup_db"      xml:"backup_db"      yaml:"backupDB"`
+	Workflows  []*workflow.Workflow   `json:"workflows"   toml:"workflow"       xml:"workflow"       yaml:"workflows"`
 	*logs.LogConfig
 	*apps.Apps
 	*website.Server `json:"-" toml:"-" xml:"-" yaml:"-"`
@@ -243,6 +253,12 @@ func (c *Config) setup(logger *logs.Logger, flag *Flags) *triggers.Actions {
 		WatchFiles: c.WatchFiles,
 		LogFiles:   c.LogConfig.GetActiveLogFilePaths(),
 		Commands:   c.Commands,
+		CmdQueue:   c.CmdQueue,
+		Workflows:  c.Workflows,
+		DataStore:  c.DataStore,
+		StuckItems: c.StuckItems,
+		Archive:    c.Archive,
+		BackupDB:   c.BackupDB,
 		ClientInfo: clientinfo,
 		ConfigFile: flag.ConfigFile,
 		AutoUpdate: c.AutoUpdate,
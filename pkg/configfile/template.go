@@ -158,6 +158,90 @@ serial = {{.Serial}}
 ## Setting this to 0 will take the default of 4. Use 1 to disable retrying.
 retries = {{.Retries}}
 
+## The data store keeps app queues, queue history, Plex sessions and dashboard states in memory.
+## Set persist to true to save them next to this config file when the app stops, and load them when it starts.
+## keys limits which are saved: dashboard, plexCurrentSessions, stuckTracker, queueHistory, lidarr, radarr, readarr, sonarr,
+## and backupDBLidarr, backupDBProwlarr, backupDBRadarr, backupDBReadarr, backupDBSonarr, backupDBPlex, backupDBTautulli.
+## Empty keys saves all of them.
+## Saved items older than max_age are discarded, except the backupDB keys.
+## Prune settings are applied when the app starts, not on reload.
+## prune_after removes queues that have not been used in this long. See /api/data to inspect the store.
+{{- if .DataStore}}
+[data_store]
+  persist        = {{.DataStore.Persist}}
+  keys           = [{{range $s := .DataStore.Keys}}"{{$s}}",{{end}}]
+  max_age        = "{{.DataStore.MaxAge}}"
+  prune_interval = "{{.DataStore.PruneInterval}}"
+  prune_after    = "{{.DataStore.PruneAfter}}"
+{{- else}}
+#[data_store]
+#  persist        = false
+#  keys           = ["dashboard", "plexCurrentSessions"]
+#  max_age        = "24h"
+#  prune_interval = "5m"
+#  prune_after    = "1h"
+{{- end}}
+
+## Stuck items are queue items that are completed, failed or have warnings or errors. They're sent to the website
+## when it enables stuck items for an instance. min_age delays sending items until they've been stuck this long.
+## stall_checks also sends downloading items whose size left did not change for this many checks (every 5 minutes).
+## A check only counts when the queue was refreshed from the app since the last check.
+## Stalled items have the "stalled" state for remediation policies. Set 0 to disable.
+{{- if .StuckItems}}
+[stuck_items]
+  min_age      = "{{.StuckItems.MinAge}}"
+  stall_checks = {{.StuckItems.StallChecks}}
+{{- else}}
+#[stuck_items]
+#  min_age      = "0s"
+#  stall_checks = 0
+{{- end}}
+
+## The backup archive keeps the starr backup files that pass the database corruption check, and are not flagged.
+## Files are saved in path, a local folder, or in an S3-compatible bucket when bucket is set (path is the key prefix).
+## Set endpoint to the S3 server, like https://s3.us-east-1.amazonaws.com or http://minio:9000.
+## daily, weekly and monthly are how many of each to keep for every instance; the newest in each is kept.
+## Set all three to 0 to keep everything. The archive has an index.json with the checksum of every file.
+## timeout is how long each request to the bucket may take; the default is 10m.
+## Archived starr backups can be validated and restored from the Triggers page, or the /api/backups endpoints.
+{{- if .Archive}}
+[backup_archive]
+  path       = '''{{toml .Archive.Path}}'''
+  bucket     = "{{.Archive.Bucket}}"
+  endpoint   = "{{.Archive.Endpoint}}"
+  region     = "{{.Archive.Region}}"
+  access_key = "{{.Archive.AccessKey}}"
+  secret_key = '''{{toml .Archive.SecretKey}}'''
+  daily      = {{.Archive.Daily}}
+  weekly     = {{.Archive.Weekly}}
+  monthly    = {{.Archive.Monthly}}{{if .Archive.Timeout.Duration}}
+  timeout    = "{{.Archive.Timeout}}"{{end}}
+{{- else}}
+#[backup_archive]
+#  path       = "/backups/starr"
+#  bucket     = ""
+#  endpoint   = ""
+#  region     = ""
+#  access_key = ""
+#  secret_key = ""
+#  daily      = 7
+#  weekly     = 4
+#  monthly    = 6
+#  timeout    = "10m"
+{{- end}}
+
+## Starr backup database checks count the rows in key tables, like Movies, Series and Episodes, and compare them
+## to the previous checked backup. max_drop is the largest drop in rows, in percent, before the backup is flagged.
+## Foreign key violations and schema migrations that go backward are also flagged. 0 uses the default of 20.
+## The counts are kept in the data store with the backupDB keys, so they can be persisted.
+{{- if .BackupDB}}
+[backup_db]
+  max_drop = {{.BackupDB.MaxDrop}}
+{{- else}}
+#[backup_db]
+#  max_drop = 20
+{{- end}}
+
 ##################
 # Starr Settings #
 ##################
@@ -169,6 +253,27 @@ retries = {{.Retries}}
 ##
 ## Examples follow. UNCOMMENT (REMOVE #), AT MINIMUM: [[header]], url, api_key
 ## Setting any application timeout to "-1s" will disable that application.
+##
+## Stuck queue items may be removed automatically with remediation policies. Policies are checked in order,
+## and the first policy matching an item's status (or tracked download state, or "stalled") and stuck time is used.
+## Removals count against the deletes limit, so deletes must be above 0. Use dry_run to see what would happen.
+## Policies without remove or blocklist do nothing. Search only works with blocklist.
+## Remediated items are reported with stuck items. Example for any starr app:
+##  [[sonarr.remediate]]
+##    status    = ["failed", "warning"]
+##    stuck_for = "6h"
+##    remove    = true
+##    blocklist = true
+##    search    = true
+##    dry_run   = true
+##
+## Downloads queued in more than one instance (same download ID or title) are reported as duplicates.
+## Set priority on each instance; the copy in the highest priority instance is kept. Set remove_duplicates
+## to true on an instance to remove its lower priority copies. This also counts against the deletes limit.
+## Nothing is removed if the highest priority is shared. Example for a 1080p instance with a 4K instance:
+##  [[radarr]]
+##    priority          = 1
+##    remove_duplicates = true
 
 {{if .Lidarr}}{{range .Lidarr}}[[lidarr]]
   name     = '''{{.Name}}'''
@@ -184,6 +289,21 @@ retries = {{.Retries}}
   {{- if .ValidSSL}}
   valid_ssl = true
   {{- end}}
+  {{- if .Priority}}
+  priority = {{.Priority}}
+  {{- end}}
+  {{- if .RemoveDupes}}
+  remove_duplicates = true
+  {{- end}}
+  {{- range $r := .Remediate}}{{if $r}}
+  [[lidarr.remediate]]
+    status    = [{{range $s := $r.Status}}"{{$s}}",{{end}}]
+    stuck_for = "{{$r.StuckFor}}"
+    remove    = {{$r.Remove}}
+    blocklist = {{$r.Blocklist}}
+    search    = {{$r.Search}}
+    dry_run   = {{$r.DryRun}}
+  {{- end}}{{end}}
 
 {{end}}
 {{else}}#[[lidarr]]
@@ -227,6 +347,21 @@ retries = {{.Retries}}
   {{- if .ValidSSL}}
   valid_ssl = true
   {{- end}}
+  {{- if .Priority}}
+  priority = {{.Priority}}
+  {{- end}}
+  {{- if .RemoveDupes}}
+  remove_duplicates = true
+  {{- end}}
+  {{- range $r := .Remediate}}{{if $r}}
+  [[radarr.remediate]]
+    status    = [{{range $s := $r.Status}}"{{$s}}",{{end}}]
+    stuck_for = "{{$r.StuckFor}}"
+    remove    = {{$r.Remove}}
+    blocklist = {{$r.Blocklist}}
+    search    = {{$r.Search}}
+    dry_run   = {{$r.DryRun}}
+  {{- end}}{{end}}
 
 {{end}}
 {{else}}#[[radarr]]
@@ -249,6 +384,21 @@ retries = {{.Retries}}
   {{- if .ValidSSL}}
   valid_ssl = true
   {{- end}}
+  {{- if .Priority}}
+  priority = {{.Priority}}
+  {{- end}}
+  {{- if .RemoveDupes}}
+  remove_duplicates = true
+  {{- end}}
+  {{- range $r := .Remediate}}{{if $r}}
+  [[readarr.remediate]]
+    status    = [{{range $s := $r.Status}}"{{$s}}",{{end}}]
+    stuck_for = "{{$r.StuckFor}}"
+    remove    = {{$r.Remove}}
+    blocklist = {{$r.Blocklist}}
+    search    = {{$r.Search}}
+    dry_run   = {{$r.DryRun}}
+  {{- end}}{{end}}
 
 {{end}}
 {{else}}#[[readarr]]
@@ -271,6 +421,21 @@ retries = {{.Retries}}
   {{- if .ValidSSL}}
   valid_ssl = true
   {{- end}}
+  {{- if .Priority}}
+  priority = {{.Priority}}
+  {{- end}}
+  {{- if .RemoveDupes}}
+  remove_duplicates = true
+  {{- end}}
+  {{- range $r := .Remediate}}{{if $r}}
+  [[sonarr.remediate]]
+    status    = [{{range $s := $r.Status}}"{{$s}}",{{end}}]
+    stuck_for = "{{$r.StuckFor}}"
+    remove    = {{$r.Remove}}
+    blocklist = {{$r.Blocklist}}
+    search    = {{$r.Search}}
+    dry_run   = {{$r.DryRun}}
+  {{- end}}{{end}}
 
 {{end}}
 {{else}}#[[sonarr]]
@@ -400,6 +565,7 @@ retries = {{.Retries}}
 #################
 
 ## Find your token: https://support.plex.tv/articles/204059436-finding-an-authentication-token-x-plex-token/
+## Set backup_path to the folder where Plex saves its scheduled database backups to check them for corruption.
 ##
 {{if and .Plex (not force)}}[plex]
   url     = '''{{.Plex.URL}}'''   # Your plex URL
@@ -409,9 +575,13 @@ retries = {{.Retries}}
   {{- if .Plex.ValidSSL}}
   valid_ssl = true
   {{- end}}
+  {{- if .Plex.BackupPath}}
+  backup_path = '''{{.Plex.BackupPath}}''' # folder with Plex database backups, to check them for corruption.
+  {{- end}}
 {{- else}}#[plex]
 #url     = "http://localhost:32400/" # Your plex URL
 #token   = "" # your plex token; get this from a web inspector
+#backup_path = "/var/lib/plexmediaserver/Library/Application Support/Plex Media Server/Plug-in Support/Databases"
 {{- end }}
 
 #####################
@@ -420,6 +590,8 @@ retries = {{.Retries}}
 
 # Enables email=>username map. Set a name to enable service checks.
 # Must uncomment [tautulli], 'api_key' and 'url' at a minimum.
+# Set backup_path to Tautulli's backup folder to check the backups for corruption on a timer.
+# Without backup_path, the timer downloads Tautulli's database through its API and checks that instead.
 {{if and .Tautulli (not force)}}
 [tautulli]
   name     = '''{{.Tautulli.Name}}''' # only set a name to enable service checks.
@@ -430,11 +602,15 @@ retries = {{.Retries}}
   {{- if .Tautulli.ValidSSL}}
   valid_ssl = true
   {{- end}}
+  {{- if .Tautulli.BackupPath}}
+  backup_path = '''{{.Tautulli.BackupPath}}''' # folder with Tautulli database backups, to check them for corruption.
+  {{- end}}
 {{- else}}
 #[tautulli]
 #  name    = "" # only set a name to enable service checks.
 #  url     = "http://localhost:8181/" # Your Tautulli URL
 #  api_key = "" # your tautulli api key; get this from settings
+#  backup_path = "/config/backups" # folder with Tautulli database backups.
 {{- end }}
 
 ##################
@@ -529,6 +705,9 @@ retries = {{.Retries}}
 ######################
 
 ## Tail a log file, regex match lines, and send notifications.
+## Set a window to collect matches for that long and send one summary instead of every line.
+## Summaries dedupe matches by the line (with numbers ignored), or by a regex capture group: dedupe_group.
+## Each deduped match in a summary includes up to 'samples' example lines (default 3).
 ## Example:
 
 #[[watch_file]]
@@ -539,6 +718,9 @@ retries = {{.Retries}}
 #  pipe  = false
 #  must_exist = false
 #  log_match  = true
+#  window     = "1m"
+#  dedupe_group = 0
+#  samples    = 3
 {{if .WatchFiles}}
 ## Configured Watch Files:
 {{- range $item := .WatchFiles}}{{if $item}}
@@ -550,7 +732,10 @@ retries = {{.Retries}}
   poll  = true{{end}}{{if $item.Pipe}}
   pipe  = true{{end}}{{if $item.MustExist}}
   must_exist = true{{end}}{{if $item.LogMatch}}
-  log_match = true{{end}}{{end}}
+  log_match = true{{end}}{{if $item.Window.Duration}}
+  window    = "{{$item.Window}}"{{end}}{{if $item.DedupeGroup}}
+  dedupe_group = {{$item.DedupeGroup}}{{end}}{{if $item.Samples}}
+  samples   = {{$item.Samples}}{{end}}{{end}}
 {{end}}{{end}}
 
 
@@ -564,6 +749,22 @@ retries = {{.Retries}}
 ## The example below allows a user to run any combination of ls -la on /usr, /home, or /tmp:
 ## command = "/bin/ls ({-la|-al|-l|-a}) ({/usr|/home|/tmp})"
 ##
+## Set shell to true to wrap the command with sh (cmd on Windows), or pick one: sh, bash, pwsh, cmd.
+## env is a list of KEY=value pairs added to the environment. Values may use filepath: to read a secret from a file.
+## dir sets the working directory, and stdin is written to the command's standard input.
+## run_as is a user, user:group, uid or uid:gid to run the command as (Linux only, requires root).
+## limit is how many copies of the command may run at once. Default is 1.
+##
+## schedule runs the command locally with a standard cron expression (minute hour day month weekday),
+## or a macro like @daily, @hourly or @every 6h. timezone is an IANA zone name like America/New_York, default is local.
+## jitter adds a random delay up to this duration to each scheduled run. Scheduled commands cannot have arguments.
+## catch_up controls runs missed while notifiarr was not running: skip (default), once, or all (up to 10).
+##
+## Each ({regex}) argument may be described with a [[command.arg]] section, in order.
+## These give the argument a name and description, and a type that is validated after the regexp:
+## int (with min and max), enum (with values), path (inside one of roots), or instance (with app, ie. sonarr).
+## ie. command = '/refresh.sh ({[0-9]+})' with an arg using type = "instance" and app = "sonarr".
+##
 ## Full Example (remove the leading # hashes to use it):
 
 #[[command]]
@@ -573,6 +774,32 @@ retries = {{.Retries}}
 #  log     = true
 #  notify  = true
 #  timeout = "10s"
+#  env     = ['SOME_KEY=value', 'API_KEY=filepath:/path/to/secret']
+#  dir     = '/tmp'
+#  run_as  = 'nobody:nogroup'
+#  stdin   = ''
+#  limit   = 1
+#  schedule = "30 3 * * *"
+#  timezone = "America/New_York"
+#  jitter   = "5m"
+#  catch_up = "once"
+
+## The command queue limits how many commands run at once, across all commands.
+## Set policy to "reject" to fail commands when no slot is free, instead of queuing them.
+## max_queued is how many commands may wait for a slot. history is how many runs are kept per command.
+{{- if .CmdQueue}}
+[command_queue]
+  concurrency = {{.CmdQueue.Concurrency}}
+  max_queued  = {{.CmdQueue.MaxQueued}}
+  policy      = "{{.CmdQueue.Policy}}"
+  history     = {{.CmdQueue.History}}
+{{- else}}
+#[command_queue]
+#  concurrency = 4
+#  max_queued  = 20
+#  policy      = "queue"
+#  history     = 25
+{{- end}}
 {{if .Commands}}
 ## Configured Commands:
 {{- range $item := .Commands}}{{if $item}}
@@ -581,9 +808,61 @@ retries = {{.Retries}}
   name    = '{{$item.Name}}'
   hash    = '{{$item.Hash}}'
   command = '''{{toml $item.Command}}'''
-  shell   = {{$item.Shell}}
+  shell   = "{{$item.Shell}}"
   log     = {{$item.Log}}
   notify  = {{$item.Notify}}
-  timeout = "{{$item.Timeout}}"{{end}}
+  timeout = "{{$item.Timeout}}"{{if $item.Env}}
+  env     = [{{range $s := $item.Env}}'''{{toml $s}}''',{{end}}]{{end}}{{if $item.Dir}}
+  dir     = '''{{toml $item.Dir}}'''{{end}}{{if $item.RunAs}}
+  run_as  = '''{{toml $item.RunAs}}'''{{end}}{{if $item.Stdin}}
+  stdin   = '''{{toml $item.Stdin}}'''{{end}}{{if $item.Limit}}
+  limit   = {{$item.Limit}}{{end}}{{if $item.Schedule}}
+  schedule = "{{$item.Schedule}}"
+  timezone = "{{$item.Timezone}}"
+  jitter   = "{{$item.Jitter}}"
+  catch_up = "{{$item.CatchUp}}"{{end}}{{range $arg := $item.ArgSpec}}{{if $arg}}
+  [[command.arg]]
+    name   = '''{{toml $arg.Name}}'''
+    desc   = '''{{toml $arg.Desc}}'''
+    type   = "{{$arg.Type}}"{{if $arg.Min}}
+    min    = {{$arg.Min}}{{end}}{{if $arg.Max}}
+    max    = {{$arg.Max}}{{end}}{{if $arg.Values}}
+    values = [{{range $s := $arg.Values}}'''{{toml $s}}''',{{end}}]{{end}}{{if $arg.Roots}}
+    roots  = [{{range $s := $arg.Roots}}'''{{toml $s}}''',{{end}}]{{end}}{{if $arg.App}}
+    app    = "{{$arg.App}}"{{end}}{{end}}{{end}}{{end}}
+{{end}}{{end}}
+
+#############
+# Workflows #
+#############
+
+## Workflows run a list of triggers and commands, in order, with the trigger/workflow/{name} API endpoint.
+## Each step has a trigger (the name shown on the Triggers page) or a command (name or hash), not both.
+## Set when to success (default), failure or always to decide if a step runs based on the step before it.
+## A trigger step fails if the trigger cannot be found, or does not finish before its timeout.
+## Steps wait up to timeout (default 10m) to finish. Some triggers start work in the background and finish early.
+##
+## Full Example (remove the leading # hashes to use it):
+
+#[[workflow]]
+#  name = 'radarr-checks'
+#  [[workflow.step]]
+#    trigger = 'Checking Radarr for database backup corruption.'
+#  [[workflow.step]]
+#    command = 'some-name-for-logs'
+#    when    = "failure"
+#    timeout = "1m"
+{{if .Workflows}}
+## Configured Workflows:
+{{- range $item := .Workflows}}{{if $item}}
+
+[[workflow]]
+  name = '''{{toml $item.Name}}'''{{range $step := $item.Steps}}{{if $step}}
+  [[workflow.step]]{{if $step.Trigger}}
+    trigger = '''{{toml $step.Trigger}}'''{{end}}{{if $step.Command}}
+    command = '''{{toml $step.Command}}'''{{end}}{{if $step.Args}}
+    args    = [{{range $s := $step.Args}}'''{{toml $s}}''',{{end}}]{{end}}{{if $step.When}}
+    when    = "{{$step.When}}"{{end}}{{if $step.Timeout.Duration}}
+    timeout = "{{$step.Timeout}}"{{end}}{{end}}{{end}}{{end}}
 {{end}}{{end}}
 `
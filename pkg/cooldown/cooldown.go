@@ -1,6 +1,7 @@
 package cooldown
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -21,6 +22,10 @@ type Timer struct {
 	track       map[string]*cooler
 	ch          chan *cooler
 	rep         chan bool
+	fn          chan func()
+	done        chan struct{} // closed when the channel watcher exits.
+	store       Store
+	err         error // from saving to the store when stopped.
 }
 
 // NewTimer returns a struct for which you can use Active().
@@ -41,10 +46,13 @@ func (t *Timer) Active(key string, coolFor time.Duration) bool {
 }
 
 // StopTimer kills the active cooler. Do not call Active() after you call this method.
-func (t *Timer) StopTimer() {
+// Active timers are saved to the store, if one was provided to Persist, and any error is returned.
+func (t *Timer) StopTimer() error {
 	t.ch <- nil // stop signal is nil.
 	<-t.rep     // wait until finished.
 	t.rep = nil // last but not least.
+
+	return t.err
 }
 
 // start sets up the Timer.
@@ -57,6 +65,8 @@ func (t *Timer) start() {
 	t.track = make(map[string]*cooler)
 	t.ch = make(chan *cooler)
 	t.rep = make(chan bool)
+	t.fn = make(chan func())
+	t.done = make(chan struct{})
 
 	ticker := time.NewTicker(tickerInterval)
 	if t.skipCleanup {
@@ -70,6 +80,12 @@ func (t *Timer) start() {
 func (t *Timer) stop(ticker *time.Ticker) {
 	ticker.Stop()
 
+	if t.store != nil {
+		if err := t.store.Save(t.list(time.Now())); err != nil {
+			t.err = fmt.Errorf("saving cooldown timers: %w", err)
+		}
+	}
+
 	for key := range t.track {
 		t.track[key] = nil
 		delete(t.track, key)
@@ -78,6 +94,7 @@ func (t *Timer) stop(ticker *time.Ticker) {
 	t.track = nil
 	close(t.ch)
 	t.ch = nil
+	close(t.done)
 	close(t.rep)
 }
 
@@ -100,6 +117,8 @@ func (t *Timer) chanWatcher(ticker *time.Ticker) {
 			cooler.Last = time.Now()
 			t.track[cooler.Key] = cooler
 			t.rep <- false
+		case fn := <-t.fn:
+			fn()
 		case now := <-ticker.C:
 			for key, val := range t.track {
 				if now.After(val.Last.Add(val.Dur)) {
@@ -1,6 +1,7 @@
 package cooldown_test
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -26,3 +27,112 @@ func TestActive(t *testing.T) {
 	cooler.StopTimer()
 	assert.False(cooler.Running(), "we just stopped it, so it should be stopped!")
 }
+
+func TestPersist(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	store := cooldown.FileStore(filepath.Join(t.TempDir(), "cooldowns.json"))
+
+	cooler := cooldown.NewTimer(true, 0)
+	assert.NoError(cooler.Persist(store), "a missing file is not an error")
+	assert.False(cooler.Active("long", time.Hour))
+	assert.False(cooler.Active("short", 20*time.Millisecond))
+	assert.NoError(cooler.StopTimer(), "stopping should save the active timers")
+
+	time.Sleep(50 * time.Millisecond)
+
+	cooler = cooldown.NewTimer(true, 0)
+	assert.NoError(cooler.Persist(store))
+	assert.True(cooler.Active("long", time.Hour), "the saved timer should still be active after a restart")
+	assert.False(cooler.Active("short", 20*time.Millisecond), "the saved timer expired while stopped")
+	assert.NoError(cooler.StopTimer())
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	cooler := cooldown.NewTimer(true, 0)
+	assert.Empty(cooler.List(), "there are no active timers yet")
+	assert.False(cooler.Active("b", time.Hour))
+	assert.False(cooler.Active("a", time.Minute))
+	assert.False(cooler.Active("c", time.Nanosecond))
+	assert.NoError(cooler.Save(), "saving without a store does nothing")
+
+	list := cooler.List()
+	if assert.Len(list, 2, "the expired key should not be listed") {
+		assert.Equal("a", list[0].Key, "the list must be sorted by key")
+		assert.Equal(time.Minute, list[0].Duration)
+		assert.LessOrEqual(list[0].Remaining, time.Minute)
+		assert.Equal("b", list[1].Key)
+	}
+
+	assert.NoError(cooler.StopTimer())
+	assert.Nil(cooler.List(), "a stopped timer has no list")
+}
+
+func TestListWhileStopping(t *testing.T) {
+	t.Parallel()
+
+	cooler := cooldown.NewTimer(true, 0)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for range 100 {
+			cooler.List() // this must not block when the timer stops.
+		}
+	}()
+
+	assert.NoError(t, cooler.StopTimer())
+	<-done
+	assert.Nil(t, cooler.List(), "a stopped timer has no list")
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	limiter := cooldown.NewTokenBucket(2, 100*time.Millisecond, false, 10*time.Millisecond)
+	assert.True(limiter.Allow("key"), "the bucket starts full")
+	assert.True(limiter.Allow("key"), "the bucket allows a burst of 2")
+	assert.False(limiter.Allow("key"), "the bucket is empty")
+	assert.True(limiter.Allow("other"), "each key has its own bucket")
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.True(limiter.Allow("key"), "a token refills every 50ms")
+	assert.False(limiter.Allow("key"), "this could return true on a slow system")
+
+	time.Sleep(150 * time.Millisecond)
+
+	assert.True(limiter.Allow("key"), "the bucket refilled and was cleaned up")
+	assert.True(limiter.Running())
+	limiter.StopLimiter()
+	assert.False(limiter.Running())
+}
+
+func TestSlidingWindow(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	limiter := cooldown.NewSlidingWindow(2, 100*time.Millisecond, false, 10*time.Millisecond)
+	assert.True(limiter.Allow("key"))
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.True(limiter.Allow("key"), "2 hits are allowed in the window")
+	assert.False(limiter.Allow("key"), "the window is full")
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.True(limiter.Allow("key"), "the first hit left the window")
+	assert.False(limiter.Allow("key"), "this could return true on a slow system")
+
+	time.Sleep(150 * time.Millisecond)
+
+	assert.True(limiter.Allow("key"), "the window is empty and was cleaned up")
+	assert.False(cooldown.NewSlidingWindow(0, time.Hour, true, 0).Allow("key"), "a max of 0 allows nothing")
+	limiter.StopLimiter()
+}
@@ -0,0 +1,213 @@
+package cooldown
+
+/* This file contains keyed rate limiters. They use the same channel model as the cooldown Timer. */
+
+import (
+	"time"
+)
+
+type limitKind int
+
+const (
+	tokenBucket limitKind = iota
+	slidingWindow
+)
+
+// hit is sent to the channel watcher for every call to Allow.
+type hit struct {
+	Key string
+	Now time.Time
+}
+
+// limit is the state for a single key.
+type limit struct {
+	// Tokens left in a token bucket, and when it was last refilled.
+	Tokens float64
+	Last   time.Time
+	// Hits in the sliding window, oldest first.
+	Hits []time.Time
+}
+
+// Limiter allows a number of hits per key over a period. Create one with NewTokenBucket or NewSlidingWindow.
+type Limiter struct {
+	skipCleanup bool
+	ticker      time.Duration
+	kind        limitKind
+	max         int
+	per         time.Duration
+	track       map[string]*limit
+	ch          chan *hit
+	rep         chan bool
+}
+
+// NewTokenBucket returns a limiter that allows bursts of up to count hits per key.
+// Tokens refill evenly, so count hits are allowed again after per has elapsed.
+// ie. NewTokenBucket(5, time.Hour, ...) allows 5 at once, then 1 more every 12 minutes.
+func NewTokenBucket(count int, per time.Duration, skipCleanup bool, cleanTimer time.Duration) *Limiter {
+	return newLimiter(tokenBucket, count, per, skipCleanup, cleanTimer)
+}
+
+// NewSlidingWindow returns a limiter that allows up to count hits per key within any window of time.
+// ie. NewSlidingWindow(5, time.Hour, ...) allows 5 hits, then the next hit after the first one is an hour old.
+func NewSlidingWindow(count int, window time.Duration, skipCleanup bool, cleanTimer time.Duration) *Limiter {
+	return newLimiter(slidingWindow, count, window, skipCleanup, cleanTimer)
+}
+
+func newLimiter(kind limitKind, count int, per time.Duration, skipCleanup bool, cleanTimer time.Duration) *Limiter {
+	limiter := &Limiter{
+		skipCleanup: skipCleanup,
+		ticker:      cleanTimer,
+		kind:        kind,
+		max:         count,
+		per:         per,
+	}
+	limiter.start()
+
+	return limiter
+}
+
+// Allow returns true if the key has not reached its limit. Allowed hits count against the limit.
+// A limiter with a count less than 1, or a period less than 1, allows nothing.
+func (l *Limiter) Allow(key string) bool {
+	l.ch <- &hit{Key: key, Now: time.Now()}
+	return <-l.rep
+}
+
+// StopLimiter kills the active limiter. Do not call Allow() after you call this method.
+func (l *Limiter) StopLimiter() {
+	l.ch <- nil // stop signal is nil.
+	<-l.rep     // wait until finished.
+	l.rep = nil // last but not least.
+}
+
+// Running returns false if StopLimiter has been called; true otherwise.
+func (l *Limiter) Running() bool {
+	return l.ch != nil
+}
+
+// Sizes returns the tracked key length and the channel queue length.
+func (l *Limiter) Sizes() (int, int) {
+	return len(l.track), len(l.ch)
+}
+
+// start sets up the Limiter.
+func (l *Limiter) start() {
+	tickerInterval := defaultTickerInterval
+	if l.ticker > 0 {
+		tickerInterval = l.ticker
+	}
+
+	l.track = make(map[string]*limit)
+	l.ch = make(chan *hit)
+	l.rep = make(chan bool)
+
+	ticker := time.NewTicker(tickerInterval)
+	if l.skipCleanup {
+		ticker.Stop()
+	}
+
+	go l.chanWatcher(ticker)
+}
+
+// stop closes everything.
+func (l *Limiter) stop(ticker *time.Ticker) {
+	ticker.Stop()
+
+	for key := range l.track {
+		l.track[key] = nil
+		delete(l.track, key)
+	}
+
+	l.track = nil
+	close(l.ch)
+	l.ch = nil
+	close(l.rep)
+}
+
+// chanWatcher runs a loop, and deletes any keys that are back to their full limit.
+func (l *Limiter) chanWatcher(ticker *time.Ticker) {
+	defer l.stop(ticker)
+
+	for {
+		select {
+		case hit := <-l.ch:
+			if hit == nil {
+				return // nil signals a stop.
+			}
+
+			if l.max < 1 || l.per < 1 {
+				l.rep <- false
+				continue
+			}
+
+			if l.track[hit.Key] == nil {
+				l.track[hit.Key] = &limit{Tokens: float64(l.max), Last: hit.Now}
+			}
+
+			l.rep <- l.allow(l.track[hit.Key], hit.Now)
+		case now := <-ticker.C:
+			for key, val := range l.track {
+				if l.full(val, now) {
+					l.track[key] = nil
+					delete(l.track, key)
+				}
+			}
+		}
+	}
+}
+
+// allow records a hit if the limit allows it.
+func (l *Limiter) allow(key *limit, now time.Time) bool {
+	switch l.kind {
+	case tokenBucket:
+		l.refill(key, now)
+
+		if key.Tokens < 1 {
+			return false
+		}
+
+		key.Tokens--
+	case slidingWindow:
+		l.slide(key, now)
+
+		if len(key.Hits) >= l.max {
+			return false
+		}
+
+		key.Hits = append(key.Hits, now)
+	}
+
+	return true
+}
+
+// full returns true if a key is back to its full limit, and no longer needs to be tracked.
+func (l *Limiter) full(key *limit, now time.Time) bool {
+	switch l.kind {
+	case tokenBucket:
+		l.refill(key, now)
+		return key.Tokens >= float64(l.max)
+	case slidingWindow:
+		l.slide(key, now)
+		return len(key.Hits) == 0
+	default:
+		return true
+	}
+}
+
+// refill adds the tokens earned since the last refill, up to max.
+func (l *Limiter) refill(key *limit, now time.Time) {
+	if elapsed := now.Sub(key.Last); elapsed > 0 {
+		key.Tokens = min(float64(l.max), key.Tokens+float64(l.max)*float64(elapsed)/float64(l.per))
+		key.Last = now
+	}
+}
+
+// slide removes hits that are older than the window.
+func (l *Limiter) slide(key *limit, now time.Time) {
+	idx := 0
+	for idx < len(key.Hits) && now.Sub(key.Hits[idx]) >= l.per {
+		idx++
+	}
+
+	key.Hits = key.Hits[idx:]
+}
@@ -0,0 +1,162 @@
+package cooldown
+
+/* This file contains the procedures to save cooldown timers to disk, and inspect the active timers. */
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+)
+
+// Cooldown is an active cooldown timer.
+type Cooldown struct {
+	// Key that is cooling down.
+	Key string `json:"key"`
+	// Duration is how long the key cools down for.
+	Duration time.Duration `json:"duration"`
+	// Last is when the key was last seen and the cooldown began.
+	Last time.Time `json:"last"`
+	// Remaining is how long until the cooldown expires. Not used by stores.
+	Remaining time.Duration `json:"remaining"`
+}
+
+// Store saves and loads cooldown timers, so they survive restarts.
+type Store interface {
+	// Load returns the saved cooldown timers. Expired timers are discarded by the caller.
+	Load() ([]*Cooldown, error)
+	// Save replaces the saved cooldown timers.
+	Save(list []*Cooldown) error
+}
+
+// FileStore saves cooldown timers to a JSON file at this path.
+type FileStore string
+
+// Persist loads active cooldown timers from a store, and saves them back to it when the timer is stopped.
+// Timers that expired while stored are discarded. Call Save to snapshot the timers without stopping.
+// The store is kept if loading returns an error, so the next save replaces the broken data.
+func (t *Timer) Persist(store Store) error {
+	list, err := store.Load()
+
+	t.do(func() {
+		t.store = store
+		now := time.Now()
+
+		for _, item := range list {
+			if item == nil || item.Key == "" || !now.Before(item.Last.Add(item.Duration)) {
+				continue // expired.
+			}
+
+			if t.track[item.Key] == nil || t.track[item.Key].Last.Before(item.Last) {
+				t.track[item.Key] = &cooler{Key: item.Key, Dur: item.Duration, Last: item.Last}
+			}
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("loading cooldown timers: %w", err)
+	}
+
+	return nil
+}
+
+// Save writes the active cooldown timers to the store provided to Persist.
+// Does nothing if there is no store.
+func (t *Timer) Save() error {
+	var (
+		store Store
+		list  []*Cooldown
+	)
+
+	t.do(func() {
+		store = t.store
+		list = t.list(time.Now())
+	})
+
+	if store == nil {
+		return nil
+	}
+
+	if err := store.Save(list); err != nil {
+		return fmt.Errorf("saving cooldown timers: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the active cooldown timers sorted by key. Returns nil if the timer is stopped.
+func (t *Timer) List() []*Cooldown {
+	var list []*Cooldown
+
+	t.do(func() { list = t.list(time.Now()) })
+
+	return list
+}
+
+// do runs a function in the channel watcher, so it may safely access the tracked keys.
+// The function does not run if the timer is stopped, even if it stops while waiting.
+func (t *Timer) do(fn func()) {
+	done := make(chan struct{})
+
+	select {
+	case t.fn <- func() {
+		defer close(done)
+		fn()
+	}:
+		<-done
+	case <-t.done:
+	}
+}
+
+// list must only be called from the channel watcher.
+func (t *Timer) list(now time.Time) []*Cooldown {
+	list := []*Cooldown{}
+
+	for _, val := range t.track {
+		if expires := val.Last.Add(val.Dur); now.Before(expires) {
+			list = append(list, &Cooldown{Key: val.Key, Duration: val.Dur, Last: val.Last, Remaining: expires.Sub(now)})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+
+	return list
+}
+
+// Load reads cooldown timers from the file. A missing file is not an error.
+func (f FileStore) Load() ([]*Cooldown, error) {
+	content, err := os.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	var list []*Cooldown
+	if err := json.Unmarshal(content, &list); err != nil {
+		return nil, fmt.Errorf("decoding file %s: %w", f, err)
+	}
+
+	return list, nil
+}
+
+// Save writes cooldown timers to a temporary file first, so a crash cannot leave a partial file.
+func (f FileStore) Save(list []*Cooldown) error {
+	content, err := json.MarshalIndent(list, "", " ")
+	if err != nil {
+		return fmt.Errorf("encoding timers: %w", err)
+	}
+
+	if err := os.WriteFile(string(f)+".tmp", content, mnd.Mode0600); err != nil {
+		return fmt.Errorf("writing file: %w", err)
+	}
+
+	if err := os.Rename(string(f)+".tmp", string(f)); err != nil {
+		return fmt.Errorf("renaming file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+package backups
+
+/* This file keeps backup files that passed the corruption check in an archive, with retention. */
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"golift.io/cnfg"
+	"golift.io/starr"
+)
+
+// indexFile is the name of the archive index, in the root of the archive.
+const indexFile = "index.json"
+
+// ArchiveConfig enables the backup archive. Backups that pass the corruption check are
+// copied into a local folder, or into an S3-compatible bucket when a bucket is provided.
+// Set all the retention counts to 0 to keep every archived backup.
+type ArchiveConfig struct {
+	// Path is a local folder, or the key prefix when a bucket is used.
+	Path      string `json:"path"      toml:"path"       xml:"path"       yaml:"path"`
+	Bucket    string `json:"bucket"    toml:"bucket"     xml:"bucket"     yaml:"bucket"`
+	Endpoint  string `json:"endpoint"  toml:"endpoint"   xml:"endpoint"   yaml:"endpoint"`
+	Region    string `json:"region"    toml:"region"     xml:"region"     yaml:"region"`
+	AccessKey string `json:"accessKey" toml:"access_key" xml:"access_key" yaml:"accessKey"`
+	SecretKey string `json:"secretKey" toml:"secret_key" xml:"secret_key" yaml:"secretKey"`
+	// Daily, Weekly and Monthly are how many days, weeks and months of backups to keep for each instance.
+	// The newest backup in each period is kept.
+	Daily   uint `json:"daily"   toml:"daily"   xml:"daily"   yaml:"daily"`
+	Weekly  uint `json:"weekly"  toml:"weekly"  xml:"weekly"  yaml:"weekly"`
+	Monthly uint `json:"monthly" toml:"monthly" xml:"monthly" yaml:"monthly"`
+	// Timeout is how long each request to the bucket may take. Backups may be large, so the default is 10 minutes.
+	Timeout cnfg.Duration `json:"timeout" toml:"timeout" xml:"timeout" yaml:"timeout"`
+}
+
+// Archived is a backup file in the archive.
+type Archived struct {
+	App      starr.App `json:"app"`
+	Int      int       `json:"instance"`
+	Name     string    `json:"name"`
+	Key      string    `json:"key"`    // path in the archive.
+	Source   string    `json:"source"` // path in the app.
+	Date     time.Time `json:"date"`   // when the app made the backup.
+	Archived time.Time `json:"archived"`
+	Size     int64     `json:"bytes"`
+	SHA256   string    `json:"sha256"`
+	URL      string    `json:"url,omitempty"` // app URL, to match the backup to the instance on restore.
+}
+
+// Index is the list of files in the archive. It's saved in the archive as index.json.
+type Index struct {
+	Updated time.Time   `json:"updated"`
+	Files   []*Archived `json:"files"`
+}
+
+// archiveStore is where archived files are saved: a local folder or an S3 bucket.
+type archiveStore interface {
+	put(ctx context.Context, key string, body io.Reader, size int64, sum string) error
+	get(ctx context.Context, key string) ([]byte, error) // returns errArchiveMissing if the key does not exist.
+	remove(ctx context.Context, key string) error
+	String() string
+}
+
+// archive promotes verified backups into the store and applies retention.
+type archive struct {
+	sync.Mutex
+	config *ArchiveConfig
+	store  archiveStore
+}
+
+// Errors returned by the archive.
+var (
+	ErrArchiveRequest = errors.New("archive request failed")
+	errArchiveMissing = errors.New("archive file does not exist")
+)
+
+// newArchive returns nil if the archive is not configured.
+func newArchive(config *ArchiveConfig) *archive {
+	switch {
+	case config == nil:
+		return nil
+	case config.Bucket != "":
+		return &archive{config: config, store: newS3Store(config)}
+	case config.Path != "":
+		return &archive{config: config, store: &localStore{root: config.Path}}
+	default:
+		return nil
+	}
+}
+
+// archiveBackup promotes a downloaded backup file into the archive, if the archive is enabled and the backup passed
+// the integrity and quick checks, and was not flagged when compared to the previous backup.
+// Errors are logged; they do not fail the corruption check.
+func (c *cmd) archiveBackup(
+	ctx context.Context,
+	input *genericInstance,
+	backupFile *starr.BackupFile,
+	localPath string,
+	backup *Info,
+) {
+	if c.archive == nil {
+		return
+	}
+
+	if backup.Integ != "ok" || backup.Quick != "ok" {
+		c.Printf("[%s requested] Not archiving %s backup file (%d): %s: failed integrity check: integ:%s, quick:%s",
+			input.event, input.name, input.int, backupFile.Path, backup.Integ, backup.Quick)
+		return
+	}
+
+	if len(backup.Flags) > 0 {
+		c.Printf("[%s requested] Not archiving %s backup file (%d): %s: flagged: %s",
+			input.event, input.name, input.int, backupFile.Path, backup.flagged())
+		return
+	}
+
+	if err := c.archive.add(ctx, input, backupFile, localPath); err != nil {
+		c.Errorf("[%s requested] Archiving %s backup file (%d) in %s: %s: %v",
+			input.event, input.name, input.int, c.archive.store, backupFile.Path, err)
+		return
+	}
+
+	c.Debugf("[%s requested] Archived %s backup file (%d) in %s: %s",
+		input.event, input.name, input.int, c.archive.store, backupFile.Path)
+}
+
+// add copies a verified backup file into the archive, adds it to the index, and removes the backups
+// for the instance that are no longer retained. The index is saved after each change.
+func (a *archive) add(ctx context.Context, input *genericInstance, file *starr.BackupFile, localPath string) error {
+	a.Lock()
+	defer a.Unlock()
+
+	index, err := a.index(ctx)
+	if err != nil {
+		return err
+	}
+
+	key := path.Join(string(input.name), strconv.Itoa(input.int), path.Base(file.Path))
+	for _, archived := range index.Files {
+		if archived.Key == key {
+			return nil // already archived.
+		}
+	}
+
+	archived, err := a.put(ctx, key, localPath)
+	if err != nil {
+		return err
+	}
+
+	archived.App = input.name
+	archived.Int = input.int
+	archived.Name = input.cName
+	archived.URL = input.url
+	archived.Source = file.Path
+	archived.Date = file.Time
+
+	index.Files = append(index.Files, archived)
+	if err := a.saveIndex(ctx, index); err != nil {
+		return err
+	}
+
+	return a.prune(ctx, index, input.name, input.int)
+}
+
+// put saves a local file in the store, and returns its size and checksum.
+func (a *archive) put(ctx context.Context, key, localPath string) (*Archived, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening backup file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, fmt.Errorf("reading backup file: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("reading backup file: %w", err)
+	}
+
+	archived := &Archived{Key: key, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil)), Archived: time.Now()}
+	if err := a.store.put(ctx, key, file, size, archived.SHA256); err != nil {
+		return nil, fmt.Errorf("saving %s in archive: %w", key, err)
+	}
+
+	return archived, nil
+}
+
+// prune removes the files for one instance that are not retained.
+func (a *archive) prune(ctx context.Context, index *Index, app starr.App, instance int) error {
+	files := []*Archived{}
+
+	for _, archived := range index.Files {
+		if archived.App == app && archived.Int == instance {
+			files = append(files, archived)
+		}
+	}
+
+	_, remove := a.config.retain(files)
+	if len(remove) == 0 {
+		return nil
+	}
+
+	removed := make(map[*Archived]bool)
+
+	for _, archived := range remove {
+		if err := a.store.remove(ctx, archived.Key); err != nil && !errors.Is(err, errArchiveMissing) {
+			return fmt.Errorf("removing %s from archive: %w", archived.Key, err)
+		}
+
+		removed[archived] = true
+	}
+
+	files = []*Archived{}
+
+	for _, archived := range index.Files {
+		if !removed[archived] {
+			files = append(files, archived)
+		}
+	}
+
+	index.Files = files
+
+	return a.saveIndex(ctx, index)
+}
+
+// retain splits one instance's archived files into those to keep, and those to remove, using
+// grandfather-father-son retention. The newest file in each day, week and month is kept, until each
+// count is reached. A file is kept if any of the three keep it. Both lists are sorted newest first.
+func (c *ArchiveConfig) retain(files []*Archived) ([]*Archived, []*Archived) {
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Date.After(files[j].Date) })
+
+	if c.Daily == 0 && c.Weekly == 0 && c.Monthly == 0 {
+		return files, nil
+	}
+
+	var (
+		keep, remove       []*Archived
+		days, weeks, month = make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	)
+
+	// period returns true if this is the first (newest) file in the period, and the period count has room.
+	period := func(seen map[string]bool, key string, count uint) bool {
+		if seen[key] || uint(len(seen)) >= count {
+			return false
+		}
+
+		seen[key] = true
+
+		return true
+	}
+
+	for _, archived := range files {
+		year, week := archived.Date.ISOWeek()
+		daily := period(days, archived.Date.Format(time.DateOnly), c.Daily)
+		weekly := period(weeks, fmt.Sprintf("%d-%d", year, week), c.Weekly)
+		monthly := period(month, archived.Date.Format("2006-01"), c.Monthly)
+
+		if daily || weekly || monthly {
+			keep = append(keep, archived)
+		} else {
+			remove = append(remove, archived)
+		}
+	}
+
+	return keep, remove
+}
+
+// index returns the archive index from the store. A missing index is an empty archive.
+func (a *archive) index(ctx context.Context) (*Index, error) {
+	data, err := a.store.get(ctx, indexFile)
+	if errors.Is(err, errArchiveMissing) {
+		return &Index{Files: []*Archived{}}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading archive index: %w", err)
+	}
+
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("decoding archive index: %w", err)
+	}
+
+	return &index, nil
+}
+
+func (a *archive) saveIndex(ctx context.Context, index *Index) error {
+	index.Updated = time.Now()
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding archive index: %w", err)
+	}
+
+	hash := sha256.Sum256(data)
+
+	err = a.store.put(ctx, indexFile, bytes.NewReader(data), int64(len(data)), hex.EncodeToString(hash[:]))
+	if err != nil {
+		return fmt.Errorf("saving archive index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package backups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetain(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday, in ISO week 20. The 13th starts week 20, and the 6th starts week 19.
+	day := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	// days returns one date per day, for count days, starting with the oldest, so retain must sort them.
+	days := func(count int) []time.Time {
+		dates := []time.Time{}
+		for idx := count - 1; idx >= 0; idx-- {
+			dates = append(dates, day.AddDate(0, 0, -idx))
+		}
+
+		return dates
+	}
+
+	tests := []struct {
+		name   string
+		config ArchiveConfig
+		dates  []time.Time
+		keep   int
+	}{
+		{name: "no retention keeps everything", dates: days(10), keep: 10},
+		{name: "daily", config: ArchiveConfig{Daily: 7}, dates: days(10), keep: 7},
+		{name: "weekly", config: ArchiveConfig{Weekly: 2}, dates: days(10), keep: 2},
+		{name: "monthly", config: ArchiveConfig{Monthly: 1}, dates: days(10), keep: 1},
+		{name: "daily and weekly overlap", config: ArchiveConfig{Daily: 3, Weekly: 2}, dates: days(10), keep: 4},
+		{name: "more room than files", config: ArchiveConfig{Daily: 30, Weekly: 8, Monthly: 12}, dates: days(3), keep: 3},
+		{
+			name:   "newest per day",
+			config: ArchiveConfig{Daily: 2},
+			dates: []time.Time{
+				day.Add(-6 * time.Hour), day, day.AddDate(0, 0, -1).Add(-6 * time.Hour),
+				day.AddDate(0, 0, -1), day.AddDate(0, 0, -2), day.AddDate(0, 0, -2).Add(-6 * time.Hour),
+			},
+			keep: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := []*Archived{}
+			for _, date := range test.dates {
+				files = append(files, &Archived{Date: date})
+			}
+
+			keep, remove := test.config.retain(files)
+			assert.Len(t, keep, test.keep, "wrong number of files kept")
+			assert.Len(t, remove, len(test.dates)-test.keep, "wrong number of files removed")
+			assert.Equal(t, day, keep[0].Date, "the newest file must always be kept first")
+
+			for idx := 1; idx < len(keep); idx++ {
+				assert.True(t, keep[idx-1].Date.After(keep[idx].Date), "kept files must be sorted newest first")
+			}
+
+			if test.name == "newest per day" {
+				assert.Equal(t, day.AddDate(0, 0, -1), keep[1].Date, "the newest file in each day is kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package backups
+
+/* This file contains the local folder and S3 bucket stores for the backup archive. */
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+)
+
+// defaultRegion is used for S3 signatures when no region is configured. Most S3-compatible servers accept it.
+const defaultRegion = "us-east-1"
+
+// maxErrorBody is how much of an S3 error response is included in the error.
+const maxErrorBody = 512
+
+// defaultS3Timeout is how long a bucket request may take when the archive has no timeout.
+const defaultS3Timeout = 10 * time.Minute
+
+// localStore saves archived files in a local folder.
+type localStore struct {
+	root string
+}
+
+func (l *localStore) String() string {
+	return l.root
+}
+
+func (l *localStore) path(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(key))
+}
+
+// put writes to a temporary file, and renames it, so a partial file is never in the archive.
+func (l *localStore) put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	filePath := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(filePath), mnd.Mode0750); err != nil {
+		return fmt.Errorf("creating archive folder: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*")
+	if err != nil {
+		return fmt.Errorf("creating archive file: %w", err)
+	}
+	defer os.Remove(file.Name()) // this fails after the rename, that's fine.
+
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return fmt.Errorf("writing archive file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("writing archive file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), filePath); err != nil {
+		return fmt.Errorf("renaming archive file: %w", err)
+	}
+
+	return nil
+}
+
+func (l *localStore) get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(l.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errArchiveMissing
+	} else if err != nil {
+		return nil, fmt.Errorf("reading archive file: %w", err)
+	}
+
+	return data, nil
+}
+
+func (l *localStore) remove(_ context.Context, key string) error {
+	err := os.Remove(l.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return errArchiveMissing
+	} else if err != nil {
+		return fmt.Errorf("removing archive file: %w", err)
+	}
+
+	return nil
+}
+
+// s3Store saves archived files in an S3-compatible bucket, using path-style requests and v4 signatures.
+type s3Store struct {
+	*ArchiveConfig
+	client *http.Client
+}
+
+func newS3Store(config *ArchiveConfig) *s3Store {
+	timeout := config.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultS3Timeout
+	}
+
+	return &s3Store{ArchiveConfig: config, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *s3Store) String() string {
+	return strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + strings.Trim(s.Path, "/")
+}
+
+func (s *s3Store) put(ctx context.Context, key string, body io.Reader, size int64, sum string) error {
+	// The signature needs the payload hash in hex; the checksum we already have is the same sha256.
+	resp, err := s.do(ctx, http.MethodPut, key, body, size, sum)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return s3Error(resp)
+}
+
+func (s *s3Store) get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, emptyHash())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errArchiveMissing
+	} else if err := s3Error(resp); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading archive file: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s *s3Store) remove(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, emptyHash())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return s3Error(resp)
+}
+
+// do sends a signed request for an object in the bucket.
+func (s *s3Store) do(
+	ctx context.Context,
+	method, key string,
+	body io.Reader,
+	size int64,
+	payloadHash string,
+) (*http.Response, error) {
+	uri := "/" + path.Join(s.Bucket, strings.Trim(s.Path, "/"), key)
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(s.Endpoint, "/")+s3Escape(uri), body)
+	if err != nil {
+		return nil, fmt.Errorf("creating archive request: %w", err)
+	}
+
+	req.ContentLength = size
+	s.sign(req, s3Escape(uri), payloadHash, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrArchiveRequest, err)
+	}
+
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 authorization header to the request.
+func (s *s3Store) sign(req *http.Request, uri, payloadHash string, now time.Time) {
+	region := s.Region
+	if region == "" {
+		region = defaultRegion
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+
+	canonical := strings.Join([]string{
+		req.Method,
+		uri,
+		"", // no query string.
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + s.SecretKey)
+	for _, part := range strings.Split(scope, "/") {
+		key = hmacSHA256(key, part)
+	}
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+hex.EncodeToString(hmacSHA256(key, toSign)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	hash := hmac.New(sha256.New, key)
+	hash.Write([]byte(data))
+
+	return hash.Sum(nil)
+}
+
+func emptyHash() string {
+	hash := sha256.Sum256(nil)
+	return hex.EncodeToString(hash[:])
+}
+
+// s3Escape encodes a path the way S3 signatures expect: everything except slashes and unreserved characters.
+func s3Escape(uri string) string {
+	var buf strings.Builder
+
+	for _, char := range []byte(uri) {
+		switch {
+		case char >= 'A' && char <= 'Z', char >= 'a' && char <= 'z', char >= '0' && char <= '9',
+			char == '-', char == '.', char == '_', char == '~', char == '/':
+			buf.WriteByte(char)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", char)
+		}
+	}
+
+	return buf.String()
+}
+
+// s3Error returns an error that includes the start of the response body if the status is not 2xx.
+func s3Error(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	return fmt.Errorf("%w: %s: %s", ErrArchiveRequest, resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,124 @@
+package backups
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golift.io/cnfg"
+)
+
+// fakeS3 returns a store for a fake S3 server that keeps objects in memory, by request path.
+// Requests without a signature, or with a payload that does not match its hash, are rejected.
+func fakeS3(t *testing.T) (*s3Store, func(path string) ([]byte, bool)) {
+	t.Helper()
+
+	var (
+		objects = make(map[string][]byte)
+		lock    sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") ||
+			req.Header.Get("X-Amz-Date") == "" {
+			http.Error(resp, "AccessDenied", http.StatusForbidden)
+			return
+		}
+
+		switch req.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(req.Body)
+			if sum := sha256.Sum256(body); hex.EncodeToString(sum[:]) != req.Header.Get("X-Amz-Content-Sha256") {
+				http.Error(resp, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+				return
+			}
+
+			objects[req.URL.Path] = body
+		case http.MethodGet:
+			body, ok := objects[req.URL.Path]
+			if !ok {
+				http.Error(resp, "NoSuchKey", http.StatusNotFound)
+				return
+			}
+
+			_, _ = resp.Write(body)
+		case http.MethodDelete:
+			delete(objects, req.URL.Path)
+			resp.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	store := newS3Store(&ArchiveConfig{
+		Path:      "/prefix/",
+		Bucket:    "bucket",
+		Endpoint:  server.URL + "/",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+
+	return store, func(path string) ([]byte, bool) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		body, ok := objects[path]
+
+		return body, ok
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	ctx := context.Background()
+	store, object := fakeS3(t)
+	body := []byte("sonarr backup file")
+	sum := sha256.Sum256(body)
+	key := "Sonarr/1/sonarr backup.zip"
+
+	assert.Equal(defaultS3Timeout, store.client.Timeout, "the client must have a timeout")
+	assert.True(strings.HasSuffix(store.String(), "/bucket/prefix"), "the path is trimmed: %s", store)
+
+	err := store.put(ctx, key, bytes.NewReader(body), int64(len(body)), hex.EncodeToString(sum[:]))
+	require.NoError(t, err)
+
+	saved, ok := object("/bucket/prefix/" + key)
+	assert.True(ok, "the object must be saved with the bucket and prefix in the path")
+	assert.Equal(body, saved)
+
+	data, err := store.get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(body, data)
+
+	err = store.put(ctx, key, bytes.NewReader(body), int64(len(body)), emptyHash())
+	require.ErrorIs(t, err, ErrArchiveRequest, "the server rejects a payload with the wrong hash")
+	assert.Contains(err.Error(), "XAmzContentSHA256Mismatch", "the error includes the response body")
+
+	_, err = store.get(ctx, "missing.zip")
+	require.ErrorIs(t, err, errArchiveMissing)
+
+	require.NoError(t, store.remove(ctx, key))
+
+	_, err = store.get(ctx, key)
+	require.ErrorIs(t, err, errArchiveMissing, "the object was removed")
+}
+
+func TestS3StoreTimeout(t *testing.T) {
+	t.Parallel()
+
+	store := newS3Store(&ArchiveConfig{Bucket: "bucket", Timeout: cnfg.Duration{Duration: time.Second}})
+	assert.Equal(t, time.Second, store.client.Timeout, "the configured timeout must be used")
+}
@@ -15,7 +15,7 @@ import (
 
 // Backup initializes a backup check for all instances of the provided app.
 func (a *Action) Backup(input *common.ActionInput, app starr.App) error {
-	switch app { //nolint:exhaustive // We only check starr apps.
+	switch app { //nolint:exhaustive // We only check starr apps, Plex and Tautulli.
 	default:
 		return fmt.Errorf("%w: %s", common.ErrInvalidApp, app)
 	case "":
@@ -26,6 +26,8 @@ func (a *Action) Backup(input *common.ActionInput, app starr.App) error {
 		a.cmd.Exec(input, TrigRadarrBackup)
 		a.cmd.Exec(input, TrigReadarrBackup)
 		a.cmd.Exec(input, TrigSonarrBackup)
+		a.cmd.Exec(input, TrigPlexBackup)
+		a.cmd.Exec(input, TrigTautulliBackup)
 	case starr.Lidarr:
 		a.cmd.Exec(input, TrigLidarrBackup)
 	case starr.Prowlarr:
@@ -36,6 +38,10 @@ func (a *Action) Backup(input *common.ActionInput, app starr.App) error {
 		a.cmd.Exec(input, TrigReadarrBackup)
 	case starr.Sonarr:
 		a.cmd.Exec(input, TrigSonarrBackup)
+	case starr.Plex:
+		a.cmd.Exec(input, TrigPlexBackup)
+	case tautulliApp:
+		a.cmd.Exec(input, TrigTautulliBackup)
 	}
 
 	return nil
@@ -166,6 +172,7 @@ func (c *cmd) sendLidarrBackups(ctx context.Context, input *common.ActionInput)
 				int:   idx + 1,
 				app:   app,
 				cName: app.Name,
+				url:   app.Config.URL,
 				skip:  !app.Enabled(),
 			})
 		}
@@ -182,6 +189,7 @@ func (c *cmd) sendProwlarrBackups(ctx context.Context, input *common.ActionInput
 				int:   idx + 1,
 				app:   app,
 				cName: app.Name,
+				url:   app.Config.URL,
 				skip:  !app.Enabled(),
 			})
 		}
@@ -198,6 +206,7 @@ func (c *cmd) sendRadarrBackups(ctx context.Context, input *common.ActionInput)
 				int:   idx + 1,
 				app:   app,
 				cName: app.Name,
+				url:   app.Config.URL,
 				skip:  !app.Enabled(),
 			})
 		}
@@ -214,6 +223,7 @@ func (c *cmd) sendReadarrBackups(ctx context.Context, input *common.ActionInput)
 				int:   idx + 1,
 				app:   app,
 				cName: app.Name,
+				url:   app.Config.URL,
 				skip:  !app.Enabled(),
 			})
 		}
@@ -228,6 +238,7 @@ func (c *cmd) sendSonarrBackups(ctx context.Context, input *common.ActionInput)
 				event: input.Type,
 				name:  starr.Sonarr,
 				cName: app.Name,
+				url:   app.Config.URL,
 				int:   idx + 1,
 				app:   app,
 				skip:  !app.Enabled(),
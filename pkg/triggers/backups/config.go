@@ -23,6 +23,13 @@ type cmd struct {
 	radarr   map[int]string
 	readarr  map[int]string
 	sonarr   map[int]string
+	plex     string // last checked Plex backup file.
+	tautulli string // last checked Tautulli backup file.
+	archive  *archive
+	check    *CheckConfig
+	// tautulliDBs lists the Tautulli databases downloaded through its API, newest first.
+	// This is the backup list when Tautulli has no backup path.
+	tautulliDBs []*starr.BackupFile
 }
 
 // Errors returned by this package.
@@ -64,6 +71,12 @@ type Info struct {
 	Size   int64     `json:"bytes,omitempty"`
 	Tables int64     `json:"tables,omitempty"`
 	Date   time.Time `json:"date,omitempty"`
+	// These are the deeper checks, compared to the previous backup.
+	FKeys     int64            `json:"foreignKeys"`
+	Migration int64            `json:"migration,omitempty"`
+	Counts    map[string]int64 `json:"counts,omitempty"`
+	Previous  *Snapshot        `json:"previous,omitempty"`
+	Flags     []string         `json:"flags,omitempty"`
 }
 
 // genericInstance is used to abstract all starr apps to reusable methods.
@@ -74,6 +87,7 @@ type genericInstance struct {
 	last  string      // app.Corrupt
 	name  starr.App   // Lidarr, Radarr, ..
 	cName string      // configured app name
+	url   string      // app URL, saved with archived backups to match them to the instance on restore.
 	int   int         // instance ID: 1, 2, 3...
 	app   interface { // all starr apps satisfy this interface. yay!
 		GetBackupFiles() ([]*starr.BackupFile, error)
@@ -90,10 +104,14 @@ type Payload struct {
 	Files []*starr.BackupFile `json:"backups"`
 }
 
-// New configures the library.
-func New(config *common.Config) *Action {
+// New configures the library. The archive and check configs may be nil.
+func New(config *common.Config, archive *ArchiveConfig, check *CheckConfig) *Action {
+	registerSnapshots()
+
 	return &Action{cmd: &cmd{
 		Config:   config,
+		archive:  newArchive(archive),
+		check:    check,
 		lidarr:   make(map[int]string),
 		prowlarr: make(map[int]string),
 		radarr:   make(map[int]string),
@@ -115,4 +133,5 @@ func (a *Action) Create() {
 	a.cmd.makeCorruptionTriggersReadarr(info)
 	a.cmd.makeCorruptionTriggersSonarr(info)
 	a.cmd.makeCorruptionTriggersProwlarr(info)
+	a.cmd.makeMediaTriggers()
 }
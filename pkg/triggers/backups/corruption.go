@@ -22,7 +22,7 @@ import (
 
 // Corruption initializes a corruption check for all instances of the provided app.
 func (a *Action) Corruption(input *common.ActionInput, app starr.App) error {
-	switch app { //nolint:exhaustive // We only check starr apps.
+	switch app { //nolint:exhaustive // We only check starr apps, Plex and Tautulli.
 	default:
 		return fmt.Errorf("%w: %s", common.ErrInvalidApp, app)
 	case "":
@@ -33,6 +33,8 @@ func (a *Action) Corruption(input *common.ActionInput, app starr.App) error {
 		a.cmd.Exec(input, TrigRadarrCorrupt)
 		a.cmd.Exec(input, TrigReadarrCorrupt)
 		a.cmd.Exec(input, TrigSonarrCorrupt)
+		a.cmd.Exec(input, TrigPlexCorrupt)
+		a.cmd.Exec(input, TrigTautulliCorrupt)
 	case starr.Lidarr:
 		a.cmd.Exec(input, TrigLidarrCorrupt)
 	case starr.Prowlarr:
@@ -43,6 +45,10 @@ func (a *Action) Corruption(input *common.ActionInput, app starr.App) error {
 		a.cmd.Exec(input, TrigReadarrCorrupt)
 	case starr.Sonarr:
 		a.cmd.Exec(input, TrigSonarrCorrupt)
+	case starr.Plex:
+		a.cmd.Exec(input, TrigPlexCorrupt)
+	case tautulliApp:
+		a.cmd.Exec(input, TrigTautulliCorrupt)
 	}
 
 	return nil
@@ -238,7 +244,7 @@ func (c *cmd) sendSonarrCorruption(ctx context.Context, input *common.ActionInpu
 	}
 }
 
-func (c *cmd) sendAndLogAppCorruption(ctx context.Context, input *genericInstance) string { //nolint:cyclop
+func (c *cmd) sendAndLogAppCorruption(ctx context.Context, input *genericInstance) string {
 	if input.skip {
 		c.Debugf("Skipping corruption check on %s: %s (%d), instance disabled.", input.name, input.cName, input.int)
 		return input.last
@@ -266,40 +272,58 @@ func (c *cmd) sendAndLogAppCorruption(ctx context.Context, input *genericInstanc
 		return input.last
 	}
 
-	backup, err := c.checkBackupFileCorruption(ctx, input, latest)
+	backup, err := c.checkBackupFileCorruption(ctx, input, fileList[0])
 	if err != nil {
 		c.Errorf("[%s requested] Checking %s Backup File Corruption (%d): %s: %v (last file: %s)",
 			input.event, input.name, input.int, latest, err, input.last)
 		return input.last
 	}
 
+	c.sendCorruption(input, backup, fileList[0])
+
+	if input.last == mnd.Disabled || input.last == "" {
+		return input.last
+	}
+
+	return latest
+}
+
+// compareBackupFile adds the app and file to a checked backup, and compares it to the previous one.
+// This must run before the backup is archived, so flagged backups are not archived.
+func (c *cmd) compareBackupFile(input *genericInstance, backup *Info, file *starr.BackupFile) {
 	backup.App = input.name
 	backup.Int = input.int
 	backup.Name = input.cName
-	backup.File = latest
-	backup.Date = fileList[0].Time.Round(time.Second)
+	backup.File = file.Path
+	backup.Date = file.Time.Round(time.Second)
+	c.compareBackup(backup)
+
+	if len(backup.Flags) > 0 {
+		c.Printf("[%s requested] %s Backup DB Check (%d): %s: flagged: %s",
+			input.event, input.name, input.int, file.Path, backup.flagged())
+	}
+}
 
+// sendCorruption sends the results of a compared backup check to the website.
+func (c *cmd) sendCorruption(input *genericInstance, backup *Info, file *starr.BackupFile) {
 	c.SendData(&website.Request{
 		Route:      website.CorruptRoute,
 		Event:      input.event,
 		LogPayload: true,
-		LogMsg: fmt.Sprintf("%s Backup File Corruption Info (%d): %s: OK: ver:%s, integ:%s, quick:%s, tables:%d, size:%d",
-			input.name, input.int, latest, backup.Ver, backup.Integ, backup.Quick, backup.Tables, backup.Size),
+		LogMsg: fmt.Sprintf("%s Backup File Corruption Info (%d): %s: OK: ver:%s, integ:%s, quick:%s, "+
+			"tables:%d, size:%d, migration:%d, flags:%d", input.name, input.int, file.Path, backup.Ver,
+			backup.Integ, backup.Quick, backup.Tables, backup.Size, backup.Migration, len(backup.Flags)),
 		Payload: backup,
 	})
-
-	if input.last == mnd.Disabled || input.last == "" {
-		return input.last
-	}
-
-	return latest
 }
 
 func (c *cmd) checkBackupFileCorruption(
 	ctx context.Context,
 	input *genericInstance,
-	remotePath string,
+	backupFile *starr.BackupFile,
 ) (*Info, error) {
+	remotePath := backupFile.Path
+
 	folder, err := os.MkdirTemp("", "notifiarr_tmp_dir")
 	if err != nil {
 		const moreInfo = "click here for help with this: https://notifiarr.wiki/en/Client/Configuration#tmp-not-found"
@@ -330,7 +354,16 @@ func (c *cmd) checkBackupFileCorruption(
 		if path.Ext(filePath) == ".db" {
 			c.Debugf("[%s requested] Checking %s backup sqlite3 file (%d): %s",
 				input.event, input.name, input.int, filePath)
-			return input.checkCorruptSQLite(ctx, filePath)
+
+			backup, err := input.checkCorruptSQLite(ctx, filePath)
+			if err != nil {
+				return backup, err
+			}
+
+			c.compareBackupFile(input, backup, backupFile)
+			c.archiveBackup(ctx, input, backupFile, fileName, backup)
+
+			return backup, nil
 		}
 	}
 
@@ -401,6 +434,7 @@ func (c *genericInstance) checkCorruptSQLite(
 	backup.Ver, _ = c.getSQLLiteRowString(ctx, conn, "select sqlite_version()")
 	backup.Integ, backup.Rows = c.getSQLLiteRowString(ctx, conn, "PRAGMA integrity_check")
 	backup.Quick, _ = c.getSQLLiteRowString(ctx, conn, "PRAGMA quick_check")
+	c.validateSQLite(ctx, conn, backup)
 
 	return backup, nil
 }
@@ -0,0 +1,346 @@
+package backups
+
+/* This file contains the database backup and corruption checks for Plex and Tautulli. */
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"golift.io/cnfg"
+	"golift.io/starr"
+)
+
+// Tautulli is not a starr app, but it's sent in the same payloads as one.
+const tautulliApp starr.App = "Tautulli"
+
+// These match the scheduled backup files that Plex and Tautulli write.
+// Plex dates its backups, and the date keeps the -wal and -shm files out.
+const (
+	plexBackupGlob     = "com.plexapp.plugins.library.db-????-??-??"
+	tautulliBackupGlob = "tautulli.backup-*.db"
+)
+
+// tautulliDBs is how many downloaded Tautulli databases are kept in the backup list.
+const tautulliDBs = 10
+
+// Trigger Types.
+const (
+	TrigPlexCorrupt     common.TriggerName = "Checking Plex for database backup corruption."
+	TrigTautulliCorrupt common.TriggerName = "Checking Tautulli for database backup corruption."
+	TrigPlexBackup      common.TriggerName = "Sending Plex Backup File List to Notifiarr."
+	TrigTautulliBackup  common.TriggerName = "Sending Tautulli Backup File List to Notifiarr."
+)
+
+// makeMediaTriggers adds the Plex and Tautulli triggers. The Plex timers run when a backup path is configured.
+// Tautulli's timers always run; without a backup path its database is downloaded through its API.
+func (c *cmd) makeMediaTriggers() {
+	var plexTimer, tautulliTimer cnfg.Duration
+
+	if c.Apps.Plex.Enabled() && c.Apps.Plex.BackupPath != "" {
+		randomTime := time.Duration(c.Config.Rand().Intn(randomMinutes))*time.Second +
+			time.Duration(c.Config.Rand().Intn(randomMinutes))*time.Minute
+		plexTimer = cnfg.Duration{Duration: checkInterval + randomTime}
+	}
+
+	if c.Apps.Tautulli.Enabled() {
+		randomTime := time.Duration(c.Config.Rand().Intn(randomMinutes))*time.Second +
+			time.Duration(c.Config.Rand().Intn(randomMinutes))*time.Minute
+		tautulliTimer = cnfg.Duration{Duration: checkInterval + randomTime}
+	}
+
+	c.Add(&common.Action{
+		Name: TrigPlexCorrupt,
+		Fn:   c.sendPlexCorruption,
+		C:    make(chan *common.ActionInput, 1),
+		D:    plexTimer,
+	}, &common.Action{
+		Name: TrigPlexBackup,
+		Fn:   c.sendPlexBackups,
+		C:    make(chan *common.ActionInput, 1),
+		D:    plexTimer,
+	}, &common.Action{
+		Name: TrigTautulliCorrupt,
+		Fn:   c.sendTautulliCorruption,
+		C:    make(chan *common.ActionInput, 1),
+		D:    tautulliTimer,
+	}, &common.Action{
+		Name: TrigTautulliBackup,
+		Fn:   c.sendTautulliBackups,
+		C:    make(chan *common.ActionInput, 1),
+		D:    tautulliTimer,
+	})
+}
+
+func (c *cmd) plexInstance(event website.EventType) *genericInstance {
+	name := c.Apps.Plex.ExtraConfig.Name
+	if name == "" && c.Apps.Plex.Server != nil {
+		name = c.Apps.Plex.Server.Name()
+	}
+
+	return &genericInstance{event: event, last: c.plex, name: starr.Plex, int: 1, cName: name}
+}
+
+func (c *cmd) tautulliInstance(event website.EventType) *genericInstance {
+	return &genericInstance{event: event, last: c.tautulli, name: tautulliApp, int: 1, cName: c.Apps.Tautulli.Name}
+}
+
+func (c *cmd) sendPlexBackups(_ context.Context, input *common.ActionInput) {
+	if !c.Apps.Plex.Enabled() || c.Apps.Plex.BackupPath == "" {
+		c.Debugf("[%s requested] Skipping Plex backup list, Plex or its backup_path is not configured.", input.Type)
+		return
+	}
+
+	c.sendLocalBackups(c.plexInstance(input.Type), c.Apps.Plex.BackupPath, plexBackupGlob)
+}
+
+// sendTautulliBackups sends the backup files in the backup path. Without a backup path,
+// it sends the list of databases downloaded through Tautulli's API by the corruption check.
+func (c *cmd) sendTautulliBackups(_ context.Context, input *common.ActionInput) {
+	if !c.Apps.Tautulli.Enabled() {
+		c.Debugf("[%s requested] Skipping Tautulli backup list, Tautulli is not configured.", input.Type)
+		return
+	}
+
+	instance := c.tautulliInstance(input.Type)
+	if c.Apps.Tautulli.BackupPath != "" {
+		c.sendLocalBackups(instance, c.Apps.Tautulli.BackupPath, tautulliBackupGlob)
+		return
+	}
+
+	if len(c.tautulliDBs) == 0 {
+		c.Printf("[%s requested] %s has no downloaded databases (%d) to list yet",
+			input.Type, instance.name, instance.int)
+		return
+	}
+
+	c.SendData(&website.Request{
+		Route:      website.BackupRoute,
+		Event:      input.Type,
+		LogPayload: true,
+		LogMsg:     fmt.Sprintf("%s Backup File List (%d)", instance.name, instance.int),
+		Payload:    &Payload{App: instance.name, Int: instance.int, Name: instance.cName, Files: c.tautulliDBs},
+	})
+}
+
+func (c *cmd) sendPlexCorruption(ctx context.Context, input *common.ActionInput) {
+	if !c.Apps.Plex.Enabled() || c.Apps.Plex.BackupPath == "" {
+		c.Debugf("[%s requested] Skipping Plex corruption check, Plex or its backup_path is not configured.", input.Type)
+		return
+	}
+
+	c.plex = c.sendLocalCorruption(ctx, c.plexInstance(input.Type), c.Apps.Plex.BackupPath, plexBackupGlob)
+}
+
+// sendTautulliCorruption checks the newest backup file in the backup path. Without a backup path
+// the database is downloaded through Tautulli's API, checked, archived, and added to the backup list.
+func (c *cmd) sendTautulliCorruption(ctx context.Context, input *common.ActionInput) {
+	if !c.Apps.Tautulli.Enabled() {
+		c.Debugf("[%s requested] Skipping Tautulli corruption check, Tautulli is not configured.", input.Type)
+		return
+	}
+
+	instance := c.tautulliInstance(input.Type)
+	if c.Apps.Tautulli.BackupPath != "" {
+		c.tautulli = c.sendLocalCorruption(ctx, instance, c.Apps.Tautulli.BackupPath, tautulliBackupGlob)
+		return
+	}
+
+	// Each download gets its own name, so it's compared to the previous download.
+	now := time.Now()
+	name := "tautulli-" + now.Format("2006-01-02_15.04.05") + ".db"
+	file := &starr.BackupFile{Name: name, Path: name, Type: "download", Time: now}
+
+	backup, err := c.checkTautulliDatabase(ctx, instance, file)
+	if err != nil {
+		c.Errorf("[%s requested] Checking Tautulli Database Corruption: %v", input.Type, err)
+		return
+	}
+
+	// Replace the list, so a backup list being sent never sees it change.
+	dbs := append([]*starr.BackupFile{file}, c.tautulliDBs...)
+	c.tautulliDBs = dbs[:min(len(dbs), tautulliDBs)]
+
+	c.sendCorruption(instance, backup, file)
+}
+
+// localBackups returns the backup files in a folder that match a pattern, newest first.
+func localBackups(folder, pattern string) ([]*starr.BackupFile, error) {
+	paths, err := filepath.Glob(filepath.Join(folder, pattern))
+	if err != nil {
+		return nil, fmt.Errorf("listing backup files: %w", err)
+	}
+
+	files := []*starr.BackupFile{}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		files = append(files, &starr.BackupFile{
+			Name: info.Name(),
+			Path: path,
+			Type: "scheduled",
+			Time: info.ModTime(),
+			Size: info.Size(),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Time.After(files[j].Time) })
+
+	return files, nil
+}
+
+// sendLocalBackups sends the list of backup files in a local folder to the website.
+func (c *cmd) sendLocalBackups(input *genericInstance, folder, pattern string) {
+	files, err := localBackups(folder, pattern)
+	if err != nil {
+		c.Errorf("[%s requested] Getting %s Backup Files (%d): %v", input.event, input.name, input.int, err)
+		return
+	} else if len(files) == 0 {
+		c.Printf("[%s requested] %s has no backup files (%d) in %s", input.event, input.name, input.int, folder)
+		return
+	}
+
+	c.SendData(&website.Request{
+		Route:      website.BackupRoute,
+		Event:      input.event,
+		LogPayload: true,
+		LogMsg:     fmt.Sprintf("%s Backup File List (%d)", input.name, input.int),
+		Payload:    &Payload{App: input.name, Int: input.int, Name: input.cName, Files: files},
+	})
+}
+
+// sendLocalCorruption checks the newest backup file in a local folder. It returns the file path,
+// so the same file is not checked again, or the last path if nothing was checked.
+func (c *cmd) sendLocalCorruption(ctx context.Context, input *genericInstance, folder, pattern string) string {
+	files, err := localBackups(folder, pattern)
+	if err != nil {
+		c.Errorf("[%s requested] Getting %s Backup Files (%d): %v", input.event, input.name, input.int, err)
+		return input.last
+	} else if len(files) == 0 {
+		c.Printf("[%s requested] %s has no backup files (%d) in %s", input.event, input.name, input.int, folder)
+		return input.last
+	}
+
+	latest := files[0]
+	if input.last == latest.Path {
+		c.Printf("[%s requested] %s Backup DB Check (%d): already checked latest file: %s",
+			input.event, input.name, input.int, latest.Path)
+		return input.last
+	}
+
+	backup, err := c.checkLocalBackupFile(ctx, input, latest)
+	if err != nil {
+		c.Errorf("[%s requested] Checking %s Backup File Corruption (%d): %s: %v (last file: %s)",
+			input.event, input.name, input.int, latest.Path, err, input.last)
+		return input.last
+	}
+
+	c.sendCorruption(input, backup, latest)
+
+	return latest.Path
+}
+
+// checkLocalBackupFile copies a backup file to a temporary folder and checks the copy,
+// so the app's own backup file is never opened for writing. A good copy without flags is archived.
+func (c *cmd) checkLocalBackupFile(
+	ctx context.Context,
+	input *genericInstance,
+	backupFile *starr.BackupFile,
+) (*Info, error) {
+	folder, err := os.MkdirTemp("", "notifiarr_tmp_dir")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary folder: %w", err)
+	}
+	defer os.RemoveAll(folder) // clean up when we're done.
+
+	source, err := os.Open(backupFile.Path)
+	if err != nil {
+		return nil, fmt.Errorf("opening backup file: %w", err)
+	}
+	defer source.Close()
+
+	copyPath := filepath.Join(folder, filepath.Base(backupFile.Path))
+
+	if err := writeFile(copyPath, source); err != nil {
+		return nil, err
+	}
+
+	c.Debugf("[%s requested] Checking %s backup sqlite3 file (%d): %s",
+		input.event, input.name, input.int, backupFile.Path)
+
+	backup, err := input.checkCorruptSQLite(ctx, copyPath)
+	if err != nil {
+		return backup, err
+	}
+
+	c.compareBackupFile(input, backup, backupFile)
+	c.archiveBackup(ctx, input, backupFile, copyPath, backup)
+
+	return backup, nil
+}
+
+// checkTautulliDatabase downloads the Tautulli database into a temporary folder and checks it.
+// A good download without flags is archived, like a local backup file.
+func (c *cmd) checkTautulliDatabase(
+	ctx context.Context,
+	input *genericInstance,
+	backupFile *starr.BackupFile,
+) (*Info, error) {
+	folder, err := os.MkdirTemp("", "notifiarr_tmp_dir")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary folder: %w", err)
+	}
+	defer os.RemoveAll(folder) // clean up when we're done.
+
+	filePath := filepath.Join(folder, "tautulli.db")
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file: %w", err)
+	}
+
+	c.Debugf("[%s requested] Downloading %s database file (%d)", input.event, input.name, input.int)
+
+	size, err := c.Apps.Tautulli.DownloadDatabase(ctx, file)
+	file.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("downloading database: %d, %w", size, err)
+	}
+
+	backupFile.Size = size
+
+	backup, err := input.checkCorruptSQLite(ctx, filePath)
+	if err != nil {
+		return backup, err
+	}
+
+	c.compareBackupFile(input, backup, backupFile)
+	c.archiveBackup(ctx, input, backupFile, filePath, backup)
+
+	return backup, nil
+}
+
+func writeFile(filePath string, source io.Reader) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer file.Close()
+
+	if size, err := io.Copy(file, source); err != nil {
+		return fmt.Errorf("writing temporary file: %d, %w", size, err)
+	}
+
+	return nil
+}
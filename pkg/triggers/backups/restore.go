@@ -0,0 +1,481 @@
+package backups
+
+/* This file restores archived backups: it validates one, then uploads it to the starr app or stages it locally. */
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"github.com/gorilla/mux"
+	"golift.io/starr"
+	"golift.io/xtractr"
+)
+
+// Restore modes. A dry run only validates the backup, and it's the default.
+const (
+	RestoreDryRun = "dryrun"
+	RestoreUpload = "upload"
+	RestoreStage  = "stage"
+)
+
+// restoreAPI is the API version each starr app uses for its restore endpoint.
+var restoreAPI = map[starr.App]string{ //nolint:gochecknoglobals
+	starr.Lidarr:   "v1",
+	starr.Prowlarr: "v1",
+	starr.Radarr:   "v3",
+	starr.Readarr:  "v1",
+	starr.Sonarr:   "v3",
+}
+
+// Errors returned by the restore methods.
+var (
+	ErrNoArchive       = errors.New("the backup archive is not configured, so there are no known-good backups")
+	ErrBackupNotFound  = errors.New("backup not found in the archive")
+	ErrBackupChecksum  = errors.New("archived backup does not match its checksum")
+	ErrBackupFailed    = errors.New("archived backup failed the integrity check")
+	ErrRestoreMode     = errors.New("unknown restore mode")
+	ErrRestoreDir      = errors.New("a folder is required to stage a backup")
+	ErrRestoreInstance = errors.New("starr app instance not found")
+	ErrRestoreMismatch = errors.New("archived backup was made by a different instance")
+	ErrRestoreDirRoot  = errors.New("backups may only be staged in the temporary folder or the local archive folder")
+)
+
+// RestoreInput is a request to restore one archived backup.
+type RestoreInput struct {
+	Event    website.EventType
+	App      starr.App
+	Instance int
+	Key      string // archive key from the list of known-good backups.
+	Mode     string // dryrun, upload or stage.
+	Dir      string // local folder to stage the backup in.
+}
+
+// Restored is the result of a restore.
+type Restored struct {
+	Mode    string    `json:"mode"`
+	Backup  *Archived `json:"backup"`
+	Check   *Info     `json:"check"`
+	Path    string    `json:"path,omitempty"` // where a backup was staged.
+	Message string    `json:"message"`
+}
+
+// Restorable returns the known-good backups for the starr apps, newest first. These are the archived backups;
+// only backups that passed the integrity checks are archived. Provide an app and instance to filter the list.
+func (a *Action) Restorable(ctx context.Context, app starr.App, instance int) ([]*Archived, error) {
+	if a.cmd.archive == nil {
+		return nil, ErrNoArchive
+	}
+
+	a.cmd.archive.Lock()
+	index, err := a.cmd.archive.index(ctx)
+	a.cmd.archive.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*Archived{}
+
+	for _, archived := range index.Files {
+		if _, ok := restoreAPI[archived.App]; !ok || (app != "" && archived.App != app) ||
+			(instance > 0 && archived.Int != instance) {
+			continue
+		}
+
+		files = append(files, archived)
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Date.After(files[j].Date) })
+
+	return files, nil
+}
+
+// Restore validates an archived backup, and in upload or stage mode, restores it.
+// Upload sends the backup to the starr app's restore endpoint; the app restarts on its own.
+// Stage extracts the backup into a new folder inside input.Dir, to copy into the app's data folder while it's stopped.
+func (a *Action) Restore(ctx context.Context, input *RestoreInput) (*Restored, error) {
+	if _, ok := restoreAPI[input.App]; !ok {
+		return nil, fmt.Errorf("%w: %s", common.ErrInvalidApp, input.App)
+	}
+
+	if input.Mode == "" {
+		input.Mode = RestoreDryRun
+	}
+
+	switch input.Mode {
+	case RestoreDryRun, RestoreUpload:
+	case RestoreStage:
+		if input.Dir == "" {
+			return nil, ErrRestoreDir
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrRestoreMode, input.Mode)
+	}
+
+	archived, err := a.findArchived(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := os.MkdirTemp("", "notifiarr_tmp_dir")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary folder: %w", err)
+	}
+	defer os.RemoveAll(folder) // clean up when we're done.
+
+	zipFile, check, err := a.cmd.validateArchived(ctx, input, archived, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := &Restored{Mode: input.Mode, Backup: archived, Check: check}
+
+	switch input.Mode {
+	case RestoreUpload:
+		err = a.cmd.uploadRestore(ctx, input, archived, zipFile)
+		restored.Message = fmt.Sprintf("Uploaded %s to %s %d. It restarts to finish the restore.",
+			path.Base(archived.Key), input.App, input.Instance)
+	case RestoreStage:
+		restored.Path, err = a.cmd.stageRestore(input, zipFile)
+		restored.Message = fmt.Sprintf("Staged %s in %s. Stop %s, then copy these files into its data folder.",
+			path.Base(archived.Key), restored.Path, input.App)
+	default:
+		restored.Message = fmt.Sprintf("Dry run: %s passed the integrity checks and can be restored.",
+			path.Base(archived.Key))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	a.cmd.Printf("[%s requested] Restore %s (%d): %s: %s", input.Event, input.App, input.Instance,
+		archived.Key, restored.Message)
+
+	return restored, nil
+}
+
+// findArchived returns the archived backup for the input key. It must belong to the input app instance.
+func (a *Action) findArchived(ctx context.Context, input *RestoreInput) (*Archived, error) {
+	files, err := a.Restorable(ctx, input.App, input.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, archived := range files {
+		if archived.Key == input.Key {
+			return archived, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s %d: %s", ErrBackupNotFound, input.App, input.Instance, input.Key)
+}
+
+// validateArchived copies an archived backup into a folder, compares its checksum to the index,
+// and checks the database inside. It returns the path to the backup zip file.
+func (c *cmd) validateArchived(
+	ctx context.Context,
+	input *RestoreInput,
+	archived *Archived,
+	folder string,
+) (string, *Info, error) {
+	body, err := c.archive.store.get(ctx, archived.Key)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading %s from archive: %w", archived.Key, err)
+	}
+
+	if sum := sha256.Sum256(body); hex.EncodeToString(sum[:]) != archived.SHA256 {
+		return "", nil, fmt.Errorf("%w: %s", ErrBackupChecksum, archived.Key)
+	}
+
+	zipFile := filepath.Join(folder, path.Base(archived.Key))
+	if err := os.WriteFile(zipFile, body, mnd.Mode0600); err != nil {
+		return "", nil, fmt.Errorf("writing temporary file: %w", err)
+	}
+
+	_, newFiles, err := xtractr.ExtractZIP(&xtractr.XFile{
+		FilePath:  zipFile,
+		OutputDir: filepath.Join(folder, "extract"),
+		FileMode:  mnd.Mode0600,
+		DirMode:   mnd.Mode0750,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("extracting backup zip file: %w", err)
+	}
+
+	instance := &genericInstance{event: input.Event, name: input.App, int: input.Instance, cName: archived.Name}
+
+	for _, filePath := range newFiles {
+		if path.Ext(filePath) != ".db" {
+			continue
+		}
+
+		check, err := instance.checkCorruptSQLite(ctx, filePath)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if check.Integ != "ok" || check.Quick != "ok" {
+			return "", check, fmt.Errorf("%w: integ:%s, quick:%s", ErrBackupFailed, check.Integ, check.Quick)
+		}
+
+		return zipFile, check, nil
+	}
+
+	return "", nil, ErrNoDBInBackup
+}
+
+// uploadRestore sends a backup zip file to the starr app's restore endpoint.
+// Instances are numbered by their order in the config, so the backup must match the instance's URL or name.
+func (c *cmd) uploadRestore(ctx context.Context, input *RestoreInput, archived *Archived, zipFile string) error {
+	config, name, err := c.starrInstance(input.App, input.Instance)
+	if err != nil {
+		return err
+	}
+
+	// Backups archived before URLs were saved only have a name to compare.
+	if (archived.URL != "" && archived.URL != config.URL) || (archived.URL == "" && archived.Name != name) {
+		return fmt.Errorf("%w: %s %d is '%s' at %s, backup is from '%s' at %s", ErrRestoreMismatch,
+			input.App, input.Instance, name, config.URL, archived.Name, archived.URL)
+	}
+
+	body, err := os.ReadFile(zipFile)
+	if err != nil {
+		return fmt.Errorf("reading backup file: %w", err)
+	}
+
+	var form bytes.Buffer
+
+	writer := multipart.NewWriter(&form)
+
+	part, err := writer.CreateFormFile("restore", filepath.Base(zipFile))
+	if err != nil {
+		return fmt.Errorf("creating upload form: %w", err)
+	}
+
+	if _, err = part.Write(body); err != nil {
+		return fmt.Errorf("creating upload form: %w", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("creating upload form: %w", err)
+	}
+
+	uri := config.URL + "/api/" + restoreAPI[input.App] + "/system/backup/restore/upload"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, &form)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Api-Key", config.APIKey)
+
+	if config.HTTPUser != "" {
+		req.SetBasicAuth(config.HTTPUser, config.HTTPPass)
+	}
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading backup: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("(%s) %w: %s", resp.Status, website.ErrNon200, uri)
+	}
+
+	return nil
+}
+
+// starrInstance returns the configuration and name for a starr app instance. Instances start at 1.
+func (c *cmd) starrInstance(app starr.App, instance int) (*starr.Config, string, error) {
+	idx := instance - 1
+
+	switch {
+	case idx < 0:
+	case app == starr.Lidarr && idx < len(c.Apps.Lidarr):
+		return c.Apps.Lidarr[idx].Config, c.Apps.Lidarr[idx].Name, nil
+	case app == starr.Prowlarr && idx < len(c.Apps.Prowlarr):
+		return c.Apps.Prowlarr[idx].Config, c.Apps.Prowlarr[idx].Name, nil
+	case app == starr.Radarr && idx < len(c.Apps.Radarr):
+		return c.Apps.Radarr[idx].Config, c.Apps.Radarr[idx].Name, nil
+	case app == starr.Readarr && idx < len(c.Apps.Readarr):
+		return c.Apps.Readarr[idx].Config, c.Apps.Readarr[idx].Name, nil
+	case app == starr.Sonarr && idx < len(c.Apps.Sonarr):
+		return c.Apps.Sonarr[idx].Config, c.Apps.Sonarr[idx].Name, nil
+	}
+
+	return nil, "", fmt.Errorf("%w: %s %d", ErrRestoreInstance, app, instance)
+}
+
+// stageRestore extracts a backup into a new folder, so nothing in the folder is overwritten.
+func (c *cmd) stageRestore(input *RestoreInput, zipFile string) (string, error) {
+	dir, err := c.stageDir(input.Dir)
+	if err != nil {
+		return "", err
+	}
+
+	folder := filepath.Join(dir, fmt.Sprintf("notifiarr-restore-%s-%d-%s",
+		input.App.Lower(), input.Instance, time.Now().Format("20060102-150405")))
+
+	_, _, err = xtractr.ExtractZIP(&xtractr.XFile{
+		FilePath:  zipFile,
+		OutputDir: folder,
+		FileMode:  mnd.Mode0600,
+		DirMode:   mnd.Mode0750,
+	})
+	if err != nil {
+		return "", fmt.Errorf("staging backup in %s: %w", folder, err)
+	}
+
+	return folder, nil
+}
+
+// stageDir returns the absolute staging folder, if it's inside the temporary folder or the local archive folder.
+// Symlinks are resolved first, so a link cannot point the staged files somewhere else.
+func (c *cmd) stageDir(dir string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("staging folder: %w", err)
+	}
+
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return "", fmt.Errorf("staging folder: %w", err)
+	}
+
+	roots := []string{os.TempDir()}
+	if c.archive != nil && c.archive.config.Bucket == "" {
+		roots = append(roots, c.archive.config.Path)
+	}
+
+	for _, root := range roots {
+		if root, err = filepath.EvalSymlinks(root); err != nil {
+			continue
+		}
+
+		if root, err = filepath.Abs(root); err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrRestoreDirRoot, dir)
+}
+
+// restoreApp returns the starr app for a lowercase name from a request path, or an empty app.
+func restoreApp(name string) starr.App {
+	for app := range restoreAPI {
+		if app.Lower() == name {
+			return app
+		}
+	}
+
+	return ""
+}
+
+// ListHandler returns the known-good backups that can be restored.
+// @Description  Returns the archived backups for every starr app. Only backups that passed the integrity checks
+// @Description  are archived. Provide an app, or an app and instance, to filter the list.
+// @Summary      List restorable backups.
+// @Tags         Triggers
+// @Produce      json
+// @Param        app       path   string  false "app name" Enum(lidarr, prowlarr, radarr, readarr, sonarr)
+// @Param        instance  path   int64   false "instance ID"
+// @Success      200  {object} apps.Respond.apiResponse{message=[]Archived} "known-good backups"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "the archive is not configured"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/backups/{app}/{instance} [get]
+// @Security     ApiKeyAuth
+func (a *Action) ListHandler(req *http.Request) (int, interface{}) {
+	vars := mux.Vars(req)
+	instance, _ := strconv.Atoi(vars["instance"])
+
+	app := restoreApp(vars["app"])
+	if vars["app"] != "" && app == "" {
+		return http.StatusBadRequest, fmt.Errorf("%w: %s", common.ErrInvalidApp, vars["app"])
+	}
+
+	files, err := a.Restorable(req.Context(), app, instance)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, files
+}
+
+// RestoreHandler validates and restores an archived backup.
+// @Description  Validates an archived backup, and restores it when the mode is upload or stage.
+// @Description  A dry run only validates the backup. Upload sends it to the app's restore endpoint,
+// @Description  and the app restarts.
+// @Description  Stage extracts it into a new folder inside dir, to copy into the app's data folder while it's stopped.
+// @Summary      Restore a backup.
+// @Tags         Triggers
+// @Produce      json
+// @Param        app       path   string  true  "app name" Enum(lidarr, prowlarr, radarr, readarr, sonarr)
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        key       query  string  true  "archive key from the backup list"
+// @Param        mode      query  string  false "restore mode, default: dryrun" Enum(dryrun, upload, stage)
+// @Param        dir       query  string  false "folder in the temp or local archive folder; required to stage"
+// @Success      200  {object} apps.Respond.apiResponse{message=Restored} "restore result"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad input, or the backup failed validation"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/backups/{app}/{instance}/restore [post]
+// @Security     ApiKeyAuth
+func (a *Action) RestoreHandler(req *http.Request) (int, interface{}) {
+	restored, err := a.Restore(req.Context(), restoreInput(req, website.EventAPI))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, restored
+}
+
+// RestoreGUIHandler validates and restores an archived backup for the web UI.
+func (a *Action) RestoreGUIHandler(response http.ResponseWriter, req *http.Request) {
+	restored, err := a.Restore(req.Context(), restoreInput(req, website.EventGUI))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Error(response, restored.Message, http.StatusOK)
+}
+
+func restoreInput(req *http.Request, event website.EventType) *RestoreInput {
+	vars := mux.Vars(req)
+	instance, _ := strconv.Atoi(vars["instance"])
+
+	return &RestoreInput{
+		Event:    event,
+		App:      restoreApp(vars["app"]),
+		Instance: instance,
+		Key:      req.FormValue("key"),
+		Mode:     req.FormValue("mode"),
+		Dir:      req.FormValue("dir"),
+	}
+}
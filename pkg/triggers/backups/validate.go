@@ -0,0 +1,189 @@
+package backups
+
+/* This file contains the deeper database checks that compare a backup to the previous backup. */
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"golift.io/starr"
+)
+
+// defaultMaxDrop is the largest drop in a key table's row count, in percent, before a backup is flagged.
+const defaultMaxDrop = 20
+
+// snapshotKey is the data store key prefix for the last checked backup. The app name and instance are appended.
+const snapshotKey = "backupDB"
+
+// CheckConfig controls the checks that compare each backup database to the previous one.
+type CheckConfig struct {
+	// MaxDrop is the largest drop in a key table's row count, in percent, before the backup is flagged.
+	// 0 uses the default of 20. 100 never flags a drop.
+	MaxDrop uint `json:"maxDrop" toml:"max_drop" xml:"max_drop" yaml:"maxDrop"`
+}
+
+// Snapshot is the row counts and schema migration version from a checked backup.
+// The last one for each instance is kept in the data store, to compare with the next backup.
+type Snapshot struct {
+	File      string           `json:"file"`
+	Date      time.Time        `json:"date"`
+	Migration int64            `json:"migration"`
+	Counts    map[string]int64 `json:"counts"`
+}
+
+// keyTables returns the tables that are counted in each app's database.
+func keyTables(app starr.App) []string {
+	switch app { //nolint:exhaustive // only these apps have database backups.
+	case starr.Lidarr:
+		return []string{"Artists", "Albums", "Tracks", "TrackFiles", "History"}
+	case starr.Prowlarr:
+		return []string{"Indexers", "History"}
+	case starr.Radarr:
+		return []string{"Movies", "MovieFiles", "History"}
+	case starr.Readarr:
+		return []string{"Authors", "Books", "BookFiles", "History"}
+	case starr.Sonarr:
+		return []string{"Series", "Episodes", "EpisodeFiles", "History"}
+	case starr.Plex:
+		return []string{"metadata_items", "media_items", "media_parts", "accounts"}
+	case tautulliApp:
+		return []string{"session_history", "users"}
+	default:
+		return nil
+	}
+}
+
+// registerSnapshots marks the snapshot keys for persistence, so comparisons survive a restart.
+// Backups are checked hours or days apart, so snapshots are kept longer than the data store's max age.
+func registerSnapshots() {
+	for _, app := range []starr.App{
+		starr.Lidarr, starr.Prowlarr, starr.Radarr, starr.Readarr, starr.Sonarr, starr.Plex, tautulliApp,
+	} {
+		data.PersistentKeep[*Snapshot](snapshotKey + string(app))
+	}
+}
+
+// validateSQLite adds the foreign key violations, schema migration version and key table row counts to the backup.
+func (c *genericInstance) validateSQLite(ctx context.Context, conn *sql.DB, backup *Info) {
+	backup.FKeys = c.countSQLiteRows(ctx, conn, "PRAGMA foreign_key_check")
+	// Starr apps track their migrations with FluentMigrator, in the VersionInfo table. Others return 0.
+	backup.Migration = c.getSQLLiteRowInt64(ctx, conn, "SELECT MAX(Version) FROM VersionInfo")
+	backup.Counts = make(map[string]int64)
+
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table'")
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	tables := make(map[string]bool)
+
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil {
+			tables[name] = true
+		}
+	}
+
+	if rows.Err() != nil {
+		return
+	}
+
+	for _, table := range keyTables(c.name) {
+		if tables[table] {
+			backup.Counts[table] = c.getSQLLiteRowInt64(ctx, conn, `SELECT count(*) FROM "`+table+`"`)
+		}
+	}
+}
+
+// countSQLiteRows returns how many rows a query returns. The pragma checks return a row for each problem.
+func (c *genericInstance) countSQLiteRows(ctx context.Context, conn *sql.DB, sql string) int64 {
+	rows, err := conn.QueryContext(ctx, sql)
+	if err != nil {
+		return 0
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		count++
+	}
+
+	if rows.Err() != nil {
+		return 0
+	}
+
+	return count
+}
+
+// compareBackup flags the backup if it has foreign key violations, if a key table lost more rows than
+// allowed since the previous backup, or if the schema migration version went backward. A backup that
+// is not flagged is saved as the previous backup for the next comparison, so a bad backup never becomes
+// the baseline.
+func (c *cmd) compareBackup(backup *Info) {
+	maxDrop := uint(defaultMaxDrop)
+	if c.check != nil && c.check.MaxDrop > 0 {
+		maxDrop = c.check.MaxDrop
+	}
+
+	if backup.FKeys > 0 {
+		backup.Flags = append(backup.Flags, fmt.Sprintf("%d foreign key violations", backup.FKeys))
+	}
+
+	key := fmt.Sprint(snapshotKey, backup.App, backup.Int)
+	current := &Snapshot{File: backup.File, Date: backup.Date, Migration: backup.Migration, Counts: backup.Counts}
+
+	if item := data.Get(key); item != nil {
+		if previous, ok := item.Data.(*Snapshot); ok && previous.File != current.File {
+			backup.Previous = previous
+			backup.Flags = append(backup.Flags, previous.compare(current, maxDrop)...)
+		}
+	}
+
+	if len(backup.Flags) == 0 {
+		data.Save(key, current)
+	}
+}
+
+// compare returns a flag for each key table that dropped more than maxDrop percent, and for a migration downgrade.
+func (s *Snapshot) compare(current *Snapshot, maxDrop uint) []string {
+	flags := []string{}
+
+	if current.Migration > 0 && current.Migration < s.Migration { // 0 means the version is unknown.
+		flags = append(flags, fmt.Sprintf("schema migration went backward from %d to %d", s.Migration, current.Migration))
+	}
+
+	tables := make([]string, 0, len(s.Counts))
+	for table := range s.Counts {
+		tables = append(tables, table)
+	}
+
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		previous, count := s.Counts[table], current.Counts[table] // a missing table counts as 0 rows.
+		if previous == 0 || count >= previous {
+			continue
+		}
+
+		if drop := float64(previous-count) / float64(previous) * 100; drop > float64(maxDrop) { //nolint:mnd
+			flags = append(flags, fmt.Sprintf("%s rows dropped %.0f%% from %d to %d", table, drop, previous, count))
+		}
+	}
+
+	return flags
+}
+
+// flagged returns the flags as one string for logs.
+func (i *Info) flagged() string {
+	if len(i.Flags) == 0 {
+		return "none"
+	}
+
+	return strings.Join(i.Flags, "; ")
+}
@@ -0,0 +1,326 @@
+// Package calendar provides an iCalendar feed of upcoming releases from every configured starr app.
+// Calendar apps subscribe to the feed using the API key as a query parameter.
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+// These are the look-ahead defaults and limits, in days.
+const (
+	defaultDays = 14
+	maxDays     = 365
+	defaultPast = 1
+)
+
+// defaultRuntime is used for episodes when the series has no runtime.
+const defaultRuntime = 30 * time.Minute
+
+// Action contains the exported methods for this package.
+type Action struct {
+	cmd *cmd
+}
+
+type cmd struct {
+	*common.Config
+}
+
+// Event is one release on the calendar.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Category    string
+	Start       time.Time
+	End         time.Time // only used for timed events.
+	AllDay      bool
+}
+
+// filter is the query parameters for the feed.
+type filter struct {
+	start       time.Time
+	end         time.Time
+	apps        map[string]bool
+	unmonitored bool
+}
+
+// New configures the library.
+func New(config *common.Config) *Action {
+	return &Action{cmd: &cmd{Config: config}}
+}
+
+// Handler returns the upcoming releases as an iCalendar feed.
+// @Description  Returns upcoming episodes, movies, albums and books from every Lidarr, Radarr, Readarr and
+// @Description  Sonarr instance as one iCalendar (.ics) feed. Calendar apps may provide the API key in the
+// @Description  apikey query parameter. Event UIDs are stable, so updated releases replace the old ones.
+// @Summary      Retrieve upcoming releases calendar.
+// @Tags         Calendar
+// @Produce      text/calendar
+// @Param        apikey       query  string  false "API key, if not provided in the X-Api-Key header"
+// @Param        days         query  int64   false "days to look ahead, default 14, max 365"
+// @Param        past         query  int64   false "days to look back, default 1"
+// @Param        apps         query  string  false "comma separated apps to include: lidarr, radarr, readarr, sonarr"
+// @Param        unmonitored  query  bool    false "include unmonitored items"
+// @Success      200  {string} string "iCalendar feed"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/calendar.ics [get]
+// @Security     ApiKeyAuth
+func (a *Action) Handler(resp http.ResponseWriter, req *http.Request) {
+	events := a.cmd.events(req.Context(), parseFilter(req))
+
+	resp.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	resp.Header().Set("Content-Disposition", `inline; filename="notifiarr.ics"`)
+
+	if _, err := resp.Write(encode(events, time.Now())); err != nil {
+		a.cmd.Errorf("Sending calendar feed: %v", err)
+	}
+}
+
+// parseFilter returns the filter from the request's query parameters.
+func parseFilter(req *http.Request) *filter {
+	query := req.URL.Query()
+	now := time.Now()
+
+	days, err := strconv.Atoi(query.Get("days"))
+	if err != nil || days < 1 {
+		days = defaultDays
+	}
+
+	past, err := strconv.Atoi(query.Get("past"))
+	if err != nil || past < 0 {
+		past = defaultPast
+	}
+
+	output := &filter{
+		start: now.AddDate(0, 0, -min(past, maxDays)),
+		end:   now.AddDate(0, 0, min(days, maxDays)),
+	}
+	output.unmonitored, _ = strconv.ParseBool(query.Get("unmonitored"))
+
+	if apps := query.Get("apps"); apps != "" {
+		output.apps = make(map[string]bool)
+		for _, app := range strings.Split(apps, ",") {
+			output.apps[strings.ToLower(strings.TrimSpace(app))] = true
+		}
+	}
+
+	return output
+}
+
+// wants returns true if the app is included by the filter.
+func (f *filter) wants(app string) bool {
+	return f.apps == nil || f.apps[app]
+}
+
+// events collects the calendar events from every app included by the filter, sorted by start time.
+// An instance that returns an error is logged and skipped, so one broken app does not break the feed.
+func (c *cmd) events(ctx context.Context, filter *filter) []*Event {
+	events := []*Event{}
+
+	if filter.wants("lidarr") {
+		events = append(events, c.lidarr(ctx, filter)...)
+	}
+
+	if filter.wants("radarr") {
+		events = append(events, c.radarr(ctx, filter)...)
+	}
+
+	if filter.wants("readarr") {
+		events = append(events, c.readarr(ctx, filter)...)
+	}
+
+	if filter.wants("sonarr") {
+		events = append(events, c.sonarr(ctx, filter)...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+
+	return events
+}
+
+func (c *cmd) lidarr(ctx context.Context, filter *filter) []*Event {
+	events := []*Event{}
+
+	for idx, app := range c.Apps.Lidarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		albums, err := app.GetCalendarContext(ctx, lidarr.Calendar{
+			Start:         filter.start,
+			End:           filter.end,
+			Unmonitored:   filter.unmonitored,
+			IncludeArtist: true,
+		})
+		if err != nil {
+			c.Errorf("Getting Lidarr calendar from %d:%s: %v", idx+1, app.URL, err)
+			continue
+		}
+
+		for _, album := range albums {
+			summary := album.Title
+			if album.Artist != nil {
+				summary = album.Artist.ArtistName + " - " + album.Title
+			}
+
+			events = append(events, &Event{
+				UID:         fmt.Sprintf("lidarr-%s-album-%d", instanceID(app.URL), album.ID),
+				Summary:     summary,
+				Description: album.Overview,
+				Category:    "Lidarr",
+				Start:       album.ReleaseDate,
+				AllDay:      true,
+			})
+		}
+	}
+
+	return events
+}
+
+func (c *cmd) radarr(ctx context.Context, filter *filter) []*Event {
+	events := []*Event{}
+
+	for idx, app := range c.Apps.Radarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		movies, err := app.GetCalendarContext(ctx, radarr.Calendar{
+			Start:       filter.start,
+			End:         filter.end,
+			Unmonitored: filter.unmonitored,
+		})
+		if err != nil {
+			c.Errorf("Getting Radarr calendar from %d:%s: %v", idx+1, app.URL, err)
+			continue
+		}
+
+		for _, movie := range movies {
+			// Each movie has up to three release dates; only the ones in range get an event.
+			for _, release := range []struct {
+				kind string
+				date time.Time
+			}{
+				{kind: "cinemas", date: movie.InCinemas},
+				{kind: "physical", date: movie.PhysicalRelease},
+				{kind: "digital", date: movie.DigitalRelease},
+			} {
+				kind, date := release.kind, release.date
+				if date.Before(filter.start) || date.After(filter.end) {
+					continue
+				}
+
+				events = append(events, &Event{
+					UID:         fmt.Sprintf("radarr-%s-movie-%d-%s", instanceID(app.URL), movie.ID, kind),
+					Summary:     fmt.Sprintf("%s (%d) - %s release", movie.Title, movie.Year, kind),
+					Description: movie.Overview,
+					Category:    "Radarr",
+					Start:       date,
+					AllDay:      true,
+				})
+			}
+		}
+	}
+
+	return events
+}
+
+func (c *cmd) readarr(ctx context.Context, filter *filter) []*Event {
+	events := []*Event{}
+
+	for idx, app := range c.Apps.Readarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		books, err := app.GetCalendarContext(ctx, readarr.Calendar{
+			Start:         filter.start,
+			End:           filter.end,
+			Unmonitored:   filter.unmonitored,
+			IncludeAuthor: true,
+		})
+		if err != nil {
+			c.Errorf("Getting Readarr calendar from %d:%s: %v", idx+1, app.URL, err)
+			continue
+		}
+
+		for _, book := range books {
+			summary := book.Title
+			if book.Author != nil {
+				summary = book.Author.AuthorName + " - " + book.Title
+			}
+
+			events = append(events, &Event{
+				UID:         fmt.Sprintf("readarr-%s-book-%d", instanceID(app.URL), book.ID),
+				Summary:     summary,
+				Description: book.Overview,
+				Category:    "Readarr",
+				Start:       book.ReleaseDate,
+				AllDay:      true,
+			})
+		}
+	}
+
+	return events
+}
+
+func (c *cmd) sonarr(ctx context.Context, filter *filter) []*Event {
+	events := []*Event{}
+
+	for idx, app := range c.Apps.Sonarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		episodes, err := app.GetCalendarContext(ctx, sonarr.Calendar{
+			Start:         filter.start,
+			End:           filter.end,
+			Unmonitored:   filter.unmonitored,
+			IncludeSeries: true,
+		})
+		if err != nil {
+			c.Errorf("Getting Sonarr calendar from %d:%s: %v", idx+1, app.URL, err)
+			continue
+		}
+
+		for _, episode := range episodes {
+			events = append(events, sonarrEvent(instanceID(app.URL), episode))
+		}
+	}
+
+	return events
+}
+
+// sonarrEvent returns a timed event that lasts as long as the series' runtime.
+func sonarrEvent(instance string, episode *sonarr.Episode) *Event {
+	event := &Event{
+		UID:         fmt.Sprintf("sonarr-%s-episode-%d", instance, episode.ID),
+		Summary:     fmt.Sprintf("S%02dE%02d - %s", episode.SeasonNumber, episode.EpisodeNumber, episode.Title),
+		Description: episode.Overview,
+		Category:    "Sonarr",
+		Start:       episode.AirDateUtc,
+		End:         episode.AirDateUtc.Add(defaultRuntime),
+	}
+
+	if episode.Series != nil {
+		event.Summary = episode.Series.Title + " " + event.Summary
+
+		if episode.Series.Runtime > 0 {
+			event.End = episode.AirDateUtc.Add(time.Duration(episode.Series.Runtime) * time.Minute)
+		}
+	}
+
+	return event
+}
@@ -0,0 +1,110 @@
+package calendar
+
+/* This file contains the iCalendar (RFC 5545) encoder for the calendar feed. */
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+)
+
+// These are the iCalendar time formats.
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+)
+
+// maxLineLength is the longest a content line may be, in octets, before it's folded.
+const maxLineLength = 75
+
+// uidDomain is appended to event UIDs to make them globally unique.
+const uidDomain = "@notifiarr.local"
+
+// uidHashBytes is how much of the app URL hash is used in event UIDs.
+const uidHashBytes = 4
+
+// instanceID returns a short hash of an app URL for event UIDs.
+// UIDs do not change when instances are added, removed or reordered.
+func instanceID(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:uidHashBytes])
+}
+
+// encode returns the events as an iCalendar feed.
+func encode(events []*Event, now time.Time) []byte {
+	var buf bytes.Buffer
+
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:-//"+mnd.Title+"//Upcoming Releases//EN")
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	writeLine(&buf, "X-WR-CALNAME:"+escape(mnd.Title+" Upcoming Releases"))
+
+	for _, event := range events {
+		writeLine(&buf, "BEGIN:VEVENT")
+		writeLine(&buf, "UID:"+event.UID+uidDomain)
+		writeLine(&buf, "DTSTAMP:"+now.UTC().Format(dateTimeFormat))
+
+		if event.AllDay {
+			writeLine(&buf, "DTSTART;VALUE=DATE:"+event.Start.Format(dateFormat))
+			writeLine(&buf, "DTEND;VALUE=DATE:"+event.Start.AddDate(0, 0, 1).Format(dateFormat))
+		} else {
+			writeLine(&buf, "DTSTART:"+event.Start.UTC().Format(dateTimeFormat))
+			writeLine(&buf, "DTEND:"+event.End.UTC().Format(dateTimeFormat))
+		}
+
+		writeLine(&buf, "SUMMARY:"+escape(event.Summary))
+
+		if event.Description != "" {
+			writeLine(&buf, "DESCRIPTION:"+escape(event.Description))
+		}
+
+		writeLine(&buf, "CATEGORIES:"+escape(event.Category))
+		writeLine(&buf, "TRANSP:TRANSPARENT")
+		writeLine(&buf, "END:VEVENT")
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+
+	return buf.Bytes()
+}
+
+// escape returns text with the iCalendar special characters escaped.
+func escape(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(text)
+}
+
+// writeLine writes a content line ending in CRLF. Long lines are folded without splitting a UTF-8 character.
+func writeLine(buf *bytes.Buffer, line string) {
+	for length := maxLineLength; len(line) > length; length = maxLineLength - 1 {
+		cut := length
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ") // folded lines begin with a space, which counts toward the length.
+
+		line = line[cut:]
+	}
+
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+// isRuneStart returns true if the byte is not a UTF-8 continuation byte.
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80 //nolint:mnd // continuation bytes are 10xxxxxx.
+}
@@ -0,0 +1,115 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{name: "plain", input: "The Show", output: "The Show"},
+		{name: "comma and semicolon", input: "one, two; three", output: `one\, two\; three`},
+		{name: "backslash first", input: `a\b,c`, output: `a\\b\,c`},
+		{name: "newlines", input: "line one\nline two\r\nline three", output: `line one\nline two\nline three`},
+		{name: "lone carriage return", input: "line\rone", output: "lineone"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.output, escape(test.input))
+		})
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		output []string // the content lines, without CRLF.
+	}{
+		{
+			name:   "short",
+			input:  "SUMMARY:short",
+			output: []string{"SUMMARY:short"},
+		},
+		{
+			name:   "exactly the limit",
+			input:  strings.Repeat("a", maxLineLength),
+			output: []string{strings.Repeat("a", maxLineLength)},
+		},
+		{
+			name:  "folded twice",
+			input: strings.Repeat("a", maxLineLength*2),
+			output: []string{
+				strings.Repeat("a", maxLineLength),
+				" " + strings.Repeat("a", maxLineLength-1),
+				" a",
+			},
+		},
+		{
+			name:  "multi-byte character is not split",
+			input: strings.Repeat("a", maxLineLength-1) + "é",
+			output: []string{
+				strings.Repeat("a", maxLineLength-1),
+				" é",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			writeLine(&buf, test.input)
+			assert.Equal(t, strings.Join(test.output, "\r\n")+"\r\n", buf.String())
+
+			for _, line := range test.output {
+				assert.LessOrEqual(t, len(line), maxLineLength, "folded lines must fit the limit in octets")
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	output := string(encode([]*Event{
+		{
+			UID:      "radarr-" + instanceID("http://radarr:7878") + "-movie-1-digital",
+			Summary:  "Movie, The (2024) - digital release",
+			Category: "Radarr",
+			Start:    time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC),
+			AllDay:   true,
+		},
+	}, now))
+
+	assert.True(t, strings.HasSuffix(output, "END:VCALENDAR\r\n"))
+	assert.Contains(t, output, "\r\nUID:radarr-"+instanceID("http://radarr:7878")+"-movie-1-digital"+uidDomain+"\r\n")
+	assert.Contains(t, output, "\r\nDTSTAMP:20240701T120000Z\r\n")
+	assert.Contains(t, output, "\r\nDTSTART;VALUE=DATE:20240704\r\nDTEND;VALUE=DATE:20240705\r\n")
+	assert.Contains(t, output, `SUMMARY:Movie\, The (2024) - digital release`)
+}
+
+func TestInstanceID(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, instanceID("http://sonarr:8989"), instanceID("http://sonarr:8989"), "must be stable")
+	assert.NotEqual(t, instanceID("http://sonarr:8989"), instanceID("http://sonarr4k:8989"))
+	assert.Len(t, instanceID("http://sonarr:8989"), uidHashBytes*2) //nolint:mnd // two hex characters per byte.
+}
@@ -0,0 +1,280 @@
+package commands
+
+/* This file contains the procedures that validate typed command arguments. */
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/commands/cmdconfig"
+)
+
+// ErrArgSpec is returned when a command's argument definitions are invalid.
+var ErrArgSpec = errors.New("invalid argument definition")
+
+// instanceCounters returns the number of instances configured for each app that may be used as an instance arg.
+//
+//nolint:gochecknoglobals
+var instanceCounters = map[string]func(*apps.Apps) int{
+	"lidarr":   func(a *apps.Apps) int { return len(a.Lidarr) },
+	"prowlarr": func(a *apps.Apps) int { return len(a.Prowlarr) },
+	"radarr":   func(a *apps.Apps) int { return len(a.Radarr) },
+	"readarr":  func(a *apps.Apps) int { return len(a.Readarr) },
+	"sonarr":   func(a *apps.Apps) int { return len(a.Sonarr) },
+}
+
+// ArgError contains the details about a single argument that failed validation.
+type ArgError struct {
+	Arg    int               `json:"arg"` // starts at 1.
+	Name   string            `json:"name,omitempty"`
+	Type   cmdconfig.ArgType `json:"type,omitempty"`
+	Value  string            `json:"value"`
+	Reason string            `json:"reason"`
+}
+
+// ArgErrors is returned when one or more provided arguments are invalid.
+// It satisfies errors.Is(err, ErrArgValue).
+type ArgErrors []*ArgError
+
+// ArgValidation is returned by the API when arguments fail validation.
+type ArgValidation struct {
+	Error string    `json:"error"`
+	Args  ArgErrors `json:"args"`
+}
+
+// ArgInfo describes an argument, so the UI and website can render a form for it.
+type ArgInfo struct {
+	*cmdconfig.Arg
+	Index     int    `json:"index"` // starts at 1.
+	Regexp    string `json:"regexp"`
+	Instances []int  `json:"instances,omitempty"` // valid IDs for instance args.
+}
+
+// String allows the GUI trigger handler to print the error message.
+func (a *ArgValidation) String() string {
+	return a.Error
+}
+
+func (a *ArgError) Error() string {
+	if a.Name != "" {
+		return fmt.Sprintf("arg %d (%s) '%s': %s", a.Arg, a.Name, a.Value, a.Reason)
+	}
+
+	return fmt.Sprintf("arg %d '%s': %s", a.Arg, a.Value, a.Reason)
+}
+
+func (a ArgErrors) Error() string {
+	msgs := make([]string, len(a))
+	for idx, err := range a {
+		msgs[idx] = err.Error()
+	}
+
+	return ErrArgValue.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Is allows errors.Is(err, ErrArgValue) to work with argument errors.
+func (a ArgErrors) Is(target error) bool {
+	return target == ErrArgValue //nolint:errorlint,goerr113
+}
+
+// setupArgSpec validates the argument definitions against the command's arguments.
+func (c *Command) setupArgSpec() error {
+	if len(c.ArgSpec) > len(c.args) {
+		return fmt.Errorf("%w: command '%s' has %d args defined, but only %d in the command",
+			ErrArgSpec, c.Name, len(c.ArgSpec), len(c.args))
+	}
+
+	for idx, spec := range c.ArgSpec {
+		if spec == nil {
+			continue
+		}
+
+		var err error
+
+		switch spec.Type {
+		case cmdconfig.ArgString, cmdconfig.ArgInt:
+		case cmdconfig.ArgEnum:
+			if len(spec.Values) == 0 {
+				err = fmt.Errorf("%w: enum requires values", ErrArgSpec)
+			}
+		case cmdconfig.ArgPath:
+			if len(spec.Roots) == 0 {
+				err = fmt.Errorf("%w: path requires roots", ErrArgSpec)
+			}
+		case cmdconfig.ArgInstance:
+			if _, ok := c.instances(spec.App); !ok {
+				err = fmt.Errorf("%w: instance requires a starr app, not '%s'", ErrArgSpec, spec.App)
+			}
+		default:
+			err = fmt.Errorf("%w: type '%s', valid: %q", ErrArgSpec, spec.Type, cmdconfig.ArgTypes())
+		}
+
+		if err != nil {
+			return fmt.Errorf("command '%s' arg %d: %w", c.Name, idx+1, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateArgs checks provided arguments against the command's regular expressions and typed arguments.
+// Returns ArgErrors with details about every invalid argument.
+func (c *Command) ValidateArgs(args []string) error {
+	if len(c.args) != len(args) {
+		return fmt.Errorf("%w: expected: %d, provided: %d", ErrArgCount, len(c.args), len(args))
+	}
+
+	var errs ArgErrors
+
+	for idx, value := range args {
+		argErr := &ArgError{Arg: idx + 1, Value: value}
+		spec := c.argSpec(idx)
+
+		if spec != nil {
+			argErr.Name = spec.Name
+			argErr.Type = spec.Type
+		}
+
+		if !c.args[idx].MatchString(value) {
+			argErr.Reason = "does not match regexp: " + c.args[idx].String()
+		} else if spec != nil {
+			argErr.Reason = c.checkArg(spec, value)
+		}
+
+		if argErr.Reason != "" {
+			errs = append(errs, argErr)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// checkArg returns the reason a value is invalid for a typed argument, or an empty string if it's valid.
+func (c *Command) checkArg(spec *cmdconfig.Arg, value string) string {
+	switch spec.Type {
+	case cmdconfig.ArgInt:
+		num, err := strconv.ParseInt(value, mnd.Base10, mnd.Bits64)
+		if err != nil {
+			return "not an integer"
+		} else if num < spec.Min {
+			return fmt.Sprintf("less than minimum %d", spec.Min)
+		} else if spec.Max != 0 && num > spec.Max {
+			return fmt.Sprintf("more than maximum %d", spec.Max)
+		}
+	case cmdconfig.ArgEnum:
+		if !slices.Contains(spec.Values, value) {
+			return fmt.Sprintf("not one of %q", spec.Values)
+		}
+	case cmdconfig.ArgPath:
+		return checkPath(spec.Roots, value)
+	case cmdconfig.ArgInstance:
+		num, err := strconv.Atoi(value)
+		if count, _ := c.instances(spec.App); err != nil || num < 1 || num > count {
+			return fmt.Sprintf("not a configured %s instance, have %d", spec.App, count)
+		}
+	case cmdconfig.ArgString:
+	}
+
+	return ""
+}
+
+// checkPath makes sure a path is inside one of the allowed roots.
+// Symlinks are resolved in the part of the path that exists, so they cannot point outside the roots.
+func checkPath(roots []string, value string) string {
+	if !filepath.IsAbs(value) {
+		return "path is not absolute"
+	}
+
+	path, err := resolvePath(filepath.Clean(value))
+	if err != nil {
+		return "checking path: " + err.Error()
+	}
+
+	for _, root := range roots {
+		root = filepath.Clean(expandHomedir(root))
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolved
+		}
+
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("not inside allowed roots %q", roots)
+}
+
+// resolvePath resolves symlinks in a path that may not exist yet. The nearest existing parent is resolved,
+// and the rest of the path is joined to it, so a missing file under a symlinked folder is checked where it lands.
+func resolvePath(path string) (string, error) {
+	missing := ""
+
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err //nolint:wrapcheck // the caller adds context.
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, missing), nil // nothing exists, not even the root.
+		}
+
+		missing = filepath.Join(filepath.Base(path), missing)
+		path = parent
+	}
+}
+
+// instances returns the number of configured instances for a starr app, and false if the app is not known.
+func (c *Command) instances(app string) (int, bool) {
+	counter, ok := instanceCounters[strings.ToLower(app)]
+	if !ok || c.apps == nil {
+		return 0, ok
+	}
+
+	return counter(c.apps), true
+}
+
+// argSpec returns the typed argument definition for an argument index, or nil if there isn't one.
+func (c *Command) argSpec(idx int) *cmdconfig.Arg {
+	if idx < len(c.ArgSpec) {
+		return c.ArgSpec[idx]
+	}
+
+	return nil
+}
+
+// ArgInfo returns the details for each of the command's arguments.
+func (c *Command) ArgInfo() []*ArgInfo {
+	output := make([]*ArgInfo, len(c.args))
+
+	for idx, reg := range c.args {
+		output[idx] = &ArgInfo{Arg: &cmdconfig.Arg{}, Index: idx + 1, Regexp: reg.String()}
+
+		if spec := c.argSpec(idx); spec != nil {
+			output[idx].Arg = spec
+		}
+
+		if count, _ := c.instances(output[idx].App); output[idx].Type == cmdconfig.ArgInstance {
+			for id := 1; id <= count; id++ {
+				output[idx].Instances = append(output[idx].Instances, id)
+			}
+		}
+	}
+
+	return output
+}
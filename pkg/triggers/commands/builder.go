@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/commands/cmdconfig"
 	"github.com/hugelgupf/go-shlex"
 )
 
@@ -25,7 +26,7 @@ type cmdBuilder struct {
 	cmd          string
 	expectedArgs []*regexp.Regexp
 	providedArgs []string
-	shell        bool
+	shell        cmdconfig.Shell
 }
 
 // getCmd returns the exec.Cmd for the provided arguments.
@@ -56,17 +57,21 @@ func (c *cmdBuilder) getCmd(ctx context.Context) (*exec.Cmd, error) {
 func (c *cmdBuilder) getArgs() ([]string, error) {
 	cmd := c.cmd
 
-	for idx, reg := range c.expectedArgs {
-		instance := idx + 1
-		cmd = strings.Replace(cmd, fmt.Sprintf("%s%d%s", argPfx, instance, argSfx), c.providedArgs[idx], 1)
-
-		if !reg.MatchString(c.providedArgs[idx]) {
-			return nil, fmt.Errorf("%w: arg %d '%s' failed regexp", ErrArgValue, instance, c.providedArgs[idx])
-		}
+	// Args are validated by the command before the builder is created.
+	for idx := range c.expectedArgs {
+		cmd = strings.Replace(cmd, fmt.Sprintf("%s%d%s", argPfx, idx+1, argSfx), c.providedArgs[idx], 1)
 	}
 
-	if runtime.GOOS != mnd.Windows && c.shell {
-		return []string{"/bin/sh", "-c", cmd}, nil
+	switch c.shell {
+	case cmdconfig.ShellAuto:
+		if runtime.GOOS != mnd.Windows {
+			return []string{"/bin/sh", "-c", cmd}, nil
+		}
+	case cmdconfig.ShellSh, cmdconfig.ShellBash:
+		return []string{string(c.shell), "-c", cmd}, nil
+	case cmdconfig.ShellPwsh:
+		return []string{"pwsh", "-NoProfile", "-NonInteractive", "-Command", cmd}, nil
+	case cmdconfig.ShellNone, cmdconfig.ShellCmd:
 	}
 
 	// Special shell-split command.
@@ -80,7 +85,7 @@ func (c *cmdBuilder) getArgs() ([]string, error) {
 		return nil, fmt.Errorf("finding command path: %w", err)
 	}
 
-	if c.shell { // if shell is set, we know it's windows.
+	if c.shell != cmdconfig.ShellNone { // auto on windows, or cmd.
 		return append([]string{"cmd", "/C"}, builtArgs...), nil
 	}
 
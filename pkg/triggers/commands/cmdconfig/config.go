@@ -2,15 +2,129 @@
 // This is in its own package to avoid an import cycle with the clientinfo package.
 package cmdconfig
 
-import "golift.io/cnfg"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golift.io/cnfg"
+)
 
 type Config struct {
-	Name    string        `json:"name"   toml:"name"    xml:"name"    yaml:"name"`
-	Hash    string        `json:"hash"   toml:"hash"    xml:"hash"    yaml:"hash"`
-	Command string        `json:"-"      toml:"command" xml:"command" yaml:"command"`
-	Shell   bool          `json:"shell"  toml:"shell"   xml:"shell"   yaml:"shell"`
-	Log     bool          `json:"log"    toml:"log"     xml:"log"     yaml:"log"`
-	Notify  bool          `json:"notify" toml:"notify"  xml:"notify"  yaml:"notify"`
-	Timeout cnfg.Duration `json:"-"      toml:"timeout" xml:"timeout" yaml:"timeout"`
-	Args    int           `json:"args"   toml:"-"       xml:"-"       yaml:"-"`
+	Name     string        `json:"name"     toml:"name"     xml:"name"     yaml:"name"`
+	Hash     string        `json:"hash"     toml:"hash"     xml:"hash"     yaml:"hash"`
+	Command  string        `json:"-"        toml:"command"  xml:"command"  yaml:"command"`
+	Shell    Shell         `json:"shell"    toml:"shell"    xml:"shell"    yaml:"shell"`
+	Log      bool          `json:"log"      toml:"log"      xml:"log"      yaml:"log"`
+	Notify   bool          `json:"notify"   toml:"notify"   xml:"notify"   yaml:"notify"`
+	Timeout  cnfg.Duration `json:"-"        toml:"timeout"  xml:"timeout"  yaml:"timeout"`
+	Env      []string      `json:"-"        toml:"env"      xml:"env"      yaml:"env"`
+	Dir      string        `json:"-"        toml:"dir"      xml:"dir"      yaml:"dir"`
+	RunAs    string        `json:"-"        toml:"run_as"   xml:"run_as"   yaml:"runAs"`
+	Stdin    string        `json:"-"        toml:"stdin"    xml:"stdin"    yaml:"stdin"`
+	Limit    uint          `json:"limit"    toml:"limit"    xml:"limit"    yaml:"limit"`
+	ArgSpec  []*Arg        `json:"argSpec"  toml:"arg"      xml:"arg"      yaml:"argSpec"`
+	Schedule string        `json:"schedule" toml:"schedule" xml:"schedule" yaml:"schedule"`
+	Timezone string        `json:"timezone" toml:"timezone" xml:"timezone" yaml:"timezone"`
+	Jitter   cnfg.Duration `json:"jitter"   toml:"jitter"   xml:"jitter"   yaml:"jitter"`
+	CatchUp  string        `json:"catchUp"  toml:"catch_up" xml:"catch_up" yaml:"catchUp"`
+	Args     int           `json:"args"     toml:"-"        xml:"-"        yaml:"-"`
+}
+
+// Arg describes one of a command's ({regex}) arguments, so forms can be rendered for it.
+// Args are matched to the command's arguments in order. The regexp is still checked for typed args.
+type Arg struct {
+	Name   string   `json:"name"             toml:"name"   xml:"name"   yaml:"name"`
+	Desc   string   `json:"desc,omitempty"   toml:"desc"   xml:"desc"   yaml:"desc"`
+	Type   ArgType  `json:"type"             toml:"type"   xml:"type"   yaml:"type"`
+	Min    int64    `json:"min,omitempty"    toml:"min"    xml:"min"    yaml:"min"`
+	Max    int64    `json:"max,omitempty"    toml:"max"    xml:"max"    yaml:"max"`
+	Values []string `json:"values,omitempty" toml:"values" xml:"value"  yaml:"values"`
+	Roots  []string `json:"roots,omitempty"  toml:"roots"  xml:"root"   yaml:"roots"`
+	App    string   `json:"app,omitempty"    toml:"app"    xml:"app"    yaml:"app"`
+}
+
+// ArgType is the type of value an argument accepts.
+type ArgType string
+
+// These are the supported argument types.
+const (
+	ArgString   ArgType = ""         // Only the regexp is checked.
+	ArgInt      ArgType = "int"      // An integer between Min and Max. Max 0 is no maximum.
+	ArgEnum     ArgType = "enum"     // One of Values.
+	ArgPath     ArgType = "path"     // An absolute path inside one of Roots.
+	ArgInstance ArgType = "instance" // A configured instance ID for App (sonarr, radarr, etc).
+)
+
+// ArgTypes returns the list of valid argument types.
+func ArgTypes() []ArgType {
+	return []ArgType{ArgString, ArgInt, ArgEnum, ArgPath, ArgInstance}
+}
+
+// Shell is the shell a command is wrapped with. An empty shell runs the command directly.
+type Shell string
+
+// These are the supported shells.
+const (
+	ShellNone Shell = ""
+	ShellAuto Shell = "true" // sh on *nix, cmd on Windows. This is what shell = true used to do.
+	ShellSh   Shell = "sh"
+	ShellBash Shell = "bash"
+	ShellPwsh Shell = "pwsh"
+	ShellCmd  Shell = "cmd"
+)
+
+// Shells returns the list of valid shell values.
+func Shells() []Shell {
+	return []Shell{ShellNone, ShellAuto, ShellSh, ShellBash, ShellPwsh, ShellCmd}
+}
+
+// UnmarshalText allows shell to be a boolean (old configs) or the name of a shell.
+func (s *Shell) UnmarshalText(text []byte) error {
+	switch shell := strings.ToLower(strings.TrimSpace(string(text))); shell {
+	case "false", "no", "off", "0":
+		*s = ShellNone
+	case "yes", "on", "1":
+		*s = ShellAuto
+	default:
+		*s = Shell(shell)
+	}
+
+	return nil
+}
+
+// UnmarshalJSON allows shell to be a boolean (old configs) or the name of a shell.
+func (s *Shell) UnmarshalJSON(data []byte) error {
+	var shell string
+	if err := json.Unmarshal(data, &shell); err == nil {
+		return s.UnmarshalText([]byte(shell))
+	}
+
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err != nil {
+		return fmt.Errorf("shell must be a boolean or the name of a shell: %w", err)
+	}
+
+	*s = ShellNone
+	if enabled {
+		*s = ShellAuto
+	}
+
+	return nil
+}
+
+// MarshalJSON sends shell as a boolean. The website expects a boolean, and does not need the shell's name.
+func (s Shell) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s != ShellNone) //nolint:wrapcheck
+}
+
+// Valid returns true if the shell is one we know how to run.
+func (s Shell) Valid() bool {
+	for _, shell := range Shells() {
+		if s == shell {
+			return true
+		}
+	}
+
+	return false
 }
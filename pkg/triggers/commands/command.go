@@ -8,17 +8,24 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/commands/cmdconfig"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/hugelgupf/go-shlex"
+	"golift.io/cnfg"
 )
 
-var ErrDisabled = errors.New("the command is disabled due to an error")
+// Errors produced by this file.
+var (
+	ErrDisabled = errors.New("the command is disabled due to an error")
+	ErrShell    = errors.New("invalid shell provided")
+)
 
 const hashLen = 64
 
@@ -28,7 +35,8 @@ const (
 )
 
 // Setup must run in the creation routine.
-func (c *Command) Setup(logger mnd.Logger, website *website.Server) {
+// Returns an error if the command's environment, working directory, run-as user, shell or schedule is invalid.
+func (c *Command) Setup(logger mnd.Logger, website *website.Server) error {
 	if c.Name == "" {
 		if args := shlex.Split(c.Command); len(args) > 0 {
 			c.Name = args[0]
@@ -48,6 +56,20 @@ func (c *Command) Setup(logger mnd.Logger, website *website.Server) {
 	if c.Timeout.Duration == 0 {
 		c.Timeout.Duration = defaultTimeout
 	}
+
+	if !c.Shell.Valid() {
+		return fmt.Errorf("command '%s': %w: %s, valid: %q", c.Name, ErrShell, c.Shell, cmdconfig.Shells())
+	}
+
+	if err := c.setupEnvironment(); err != nil {
+		return fmt.Errorf("command '%s': %w", c.Name, err)
+	}
+
+	if err := c.setupSchedule(); err != nil {
+		return fmt.Errorf("command '%s': %w", c.Name, err)
+	}
+
+	return nil
 }
 
 func (c *Command) SetupRegexpArgs() error {
@@ -61,7 +83,7 @@ func (c *Command) SetupRegexpArgs() error {
 
 	matches := regexp.MustCompile(`\({([^}]*)}\)`).FindAllStringIndex(c.cmd, -1)
 	if matches == nil {
-		return nil
+		return c.setupArgSpec()
 	}
 
 	c.args = make([]*regexp.Regexp, len(matches))
@@ -79,16 +101,31 @@ func (c *Command) SetupRegexpArgs() error {
 		c.args[idx] = regex
 	}
 
-	return nil
+	return c.setupArgSpec()
 }
 
 // run executes this command and logs the output. This is executed from the trigger channel.
+// The command runs in a go routine, because it may wait for a queue slot, and that must not block
+// the other triggers and timers. Workflow steps call RunNow to wait for the result.
 func (c *Command) run(ctx context.Context, input *common.ActionInput) {
-	_, _ = c.RunNow(ctx, input)
+	go func() {
+		defer c.log.CapturePanic()
+		_, _ = c.RunNow(ctx, input) // errors are logged by RunNow.
+	}()
 }
 
 // RunNow runs the command immediately, waits for and returns the output.
 func (c *Command) RunNow(ctx context.Context, input *common.ActionInput) (string, error) {
+	return c.runNow(ctx, input, nil)
+}
+
+// Stream runs the command immediately and calls lineFn with each line of output as it's written.
+// Cancel the context to kill the running process. Waits for and returns the output like RunNow.
+func (c *Command) Stream(ctx context.Context, input *common.ActionInput, lineFn func(string)) (string, error) {
+	return c.runNow(ctx, input, lineFn)
+}
+
+func (c *Command) runNow(ctx context.Context, input *common.ActionInput, lineFn func(string)) (string, error) {
 	if c.disable {
 		c.mu.Lock()
 		defer c.mu.Unlock()
@@ -97,7 +134,26 @@ func (c *Command) RunNow(ctx context.Context, input *common.ActionInput) (string
 		return "<command disabled>", ErrDisabled
 	}
 
-	output, elapsed, err := c.exec(ctx, input)
+	start := time.Now()
+
+	release, err := c.queue.acquire(ctx, c)
+	if err != nil {
+		c.log.Errorf("[%s requested] Custom Command '%s' Not Run: %v", input.Type, c.Name, err)
+		c.saveHistory(&Run{
+			Start:  start,
+			Queued: cnfg.Duration{Duration: time.Since(start)},
+			Exit:   -1,
+			Source: input.Type,
+			Args:   input.Args,
+			Error:  err.Error(),
+		})
+
+		return "<command not run>", err
+	}
+	defer release()
+
+	queued := time.Since(start)
+	output, elapsed, err := c.exec(ctx, input, lineFn)
 	oStr := output.String()
 	eStr := ""
 
@@ -122,6 +178,16 @@ func (c *Command) RunNow(ctx context.Context, input *common.ActionInput) (string
 	}
 
 	c.logOutput(input, oStr, eStr, elapsed, err)
+	c.saveHistory(&Run{
+		Start:   start.Add(queued),
+		Elapsed: cnfg.Duration{Duration: elapsed},
+		Queued:  cnfg.Duration{Duration: queued},
+		Exit:    ExitCode(err),
+		Source:  input.Type,
+		Args:    input.Args,
+		Output:  oStr,
+		Error:   eStr,
+	})
 
 	return oStr, err
 }
@@ -134,6 +200,7 @@ func (c *Command) logOutput(input *common.ActionInput, oStr, eStr string, elapse
 	c.lastRun = time.Now().Round(time.Second)
 	c.output = oStr
 	c.lastArg = input.Args
+	c.exit = ExitCode(err)
 
 	extra := ""
 	if len(c.lastArg) > 0 {
@@ -161,10 +228,19 @@ func (c *Command) logOutput(input *common.ActionInput, oStr, eStr string, elapse
 }
 
 // run read locks and runs the command then returns the output.
-func (c *Command) exec(ctx context.Context, input *common.ActionInput) (*bytes.Buffer, time.Duration, error) {
+// If lineFn is not nil, it's called with each line of output as it's written.
+func (c *Command) exec(
+	ctx context.Context,
+	input *common.ActionInput,
+	lineFn func(string),
+) (*bytes.Buffer, time.Duration, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	if err := c.ValidateArgs(input.Args); err != nil {
+		return bytes.NewBufferString(err.Error()), 0, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.Timeout.Duration)
 	defer cancel()
 
@@ -177,12 +253,21 @@ func (c *Command) exec(ctx context.Context, input *common.ActionInput) (*bytes.B
 
 	cmd, err := builder.getCmd(ctx)
 	if err != nil {
-		return nil, 0, err
+		return bytes.NewBufferString(err.Error()), 0, err
 	}
 
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &out
+	c.setEnvironment(cmd)
+
+	if lineFn != nil {
+		lines := &lineWriter{fn: lineFn}
+		defer lines.flush()
+
+		cmd.Stdout = io.MultiWriter(&out, lines)
+		cmd.Stderr = cmd.Stdout // same writer means one go routine writes to it.
+	}
 
 	start := time.Now()
 	if err := cmd.Run(); err != nil {
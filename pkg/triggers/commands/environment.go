@@ -0,0 +1,95 @@
+package commands
+
+/* This file contains the procedures that validate and apply a command's environment, working directory and user. */
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	homedir "github.com/mitchellh/go-homedir"
+	"golift.io/cnfgfile"
+)
+
+// Errors produced by this file.
+var (
+	ErrEnvFormat = errors.New("env values must be in KEY=value format")
+	ErrNotDir    = errors.New("working directory is not a directory")
+	ErrRunAs     = errors.New("invalid run_as user")
+)
+
+// setupEnvironment validates the environment, working directory and run-as user.
+// Secrets in env values may be read from a file with the filepath: prefix.
+func (c *Command) setupEnvironment() error {
+	c.env = make([]string, len(c.Env))
+
+	for idx, env := range c.Env {
+		key, val, ok := strings.Cut(env, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return fmt.Errorf("%w: env %d: %s", ErrEnvFormat, idx+1, key)
+		}
+
+		_, err := cnfgfile.Parse(&val, &cnfgfile.Opts{
+			Name:          mnd.Title + " Command " + c.Name + " Env " + key,
+			TransformPath: expandHomedir,
+			Prefix:        "filepath:",
+		})
+		if err != nil {
+			return fmt.Errorf("env %s: %w", key, err)
+		}
+
+		c.env[idx] = key + "=" + val
+	}
+
+	if c.Dir != "" {
+		c.Dir = expandHomedir(c.Dir)
+
+		stat, err := os.Stat(c.Dir)
+		if err != nil {
+			return fmt.Errorf("working directory: %w", err)
+		} else if !stat.IsDir() {
+			return fmt.Errorf("%w: %s", ErrNotDir, c.Dir)
+		}
+	}
+
+	if c.runAs = nil; c.RunAs != "" {
+		var err error
+		if c.runAs, err = lookupRunAs(c.RunAs); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrRunAs, c.RunAs, err)
+		}
+	}
+
+	return nil
+}
+
+// setEnvironment applies the environment, working directory, stdin and run-as user to a command.
+func (c *Command) setEnvironment(cmd *exec.Cmd) {
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+
+	if c.Dir != "" {
+		cmd.Dir = c.Dir
+	}
+
+	if c.Stdin != "" {
+		cmd.Stdin = strings.NewReader(c.Stdin)
+	}
+
+	if c.runAs != nil {
+		setRunAs(cmd, c.runAs)
+	}
+}
+
+// expandHomedir expands a ~ to a homedir, or returns the original path in case of any error.
+func expandHomedir(filePath string) string {
+	expanded, err := homedir.Expand(filePath)
+	if err != nil {
+		return filePath
+	}
+
+	return expanded
+}
@@ -0,0 +1,122 @@
+package commands
+
+/* This file contains the run history kept for each command. */
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"github.com/gorilla/mux"
+	"golift.io/cnfg"
+)
+
+// ErrUnknownCmd is returned by the history handler when the hash does not match a command.
+var ErrUnknownCmd = errors.New("no command found with provided hash")
+
+// maxHistoryOutput is how much output is kept with each run in the history.
+const maxHistoryOutput = 4096
+
+// historyKey is prefixed to the command hash to store its history in the data cache.
+const historyKey = "commandHistory"
+
+// Run is a single command run stored in the history.
+type Run struct {
+	Start   time.Time         `json:"start"`
+	Elapsed cnfg.Duration     `json:"elapsed"`
+	Queued  cnfg.Duration     `json:"queued"`
+	Exit    int               `json:"exit"`
+	Source  website.EventType `json:"source"`
+	Args    []string          `json:"args,omitempty"`
+	Output  string            `json:"output,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// CmdHistory is returned by the history API handler.
+type CmdHistory struct {
+	Name    string `json:"name"`
+	Hash    string `json:"hash"`
+	Running int    `json:"running"`
+	Runs    []*Run `json:"runs"`
+}
+
+// saveHistory adds a run to the front of the command's history.
+// History is kept in the data cache, so it survives reloads, and restarts when the data store persists.
+func (c *Command) saveHistory(run *Run) {
+	if len(run.Output) > maxHistoryOutput {
+		run.Output = "..." + run.Output[len(run.Output)-maxHistoryOutput:]
+	}
+
+	size := uint(DefaultHistory)
+	if c.queue != nil {
+		size = c.queue.History
+	}
+
+	c.hmu.Lock()
+	defer c.hmu.Unlock()
+
+	runs := append([]*Run{run}, c.History()...)
+	if uint(len(runs)) > size {
+		runs = runs[:size]
+	}
+
+	data.Save(historyKey+c.Hash, runs)
+}
+
+// History returns the most recent runs for a command, newest first.
+func (c *Command) History() []*Run {
+	if item := data.Get(historyKey + c.Hash); item != nil {
+		if runs, ok := item.Data.([]*Run); ok {
+			return runs
+		}
+	}
+
+	return nil
+}
+
+// @Description  Returns the run history for a single command.
+// @Summary      Retrieve command run history.
+// @Tags         Triggers
+// @Produce      json
+// @Param        hash  path   string  true  "command hash"
+// @Success      200  {object} apps.Respond.apiResponse{message=[]CmdHistory} "the command and its runs"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "invalid hash"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/commands/history/{hash} [get]
+// @Security     ApiKeyAuth
+func _() {}
+
+// HistoryHandler returns the run history for one or all commands.
+// @Description  Returns the run history for all commands, newest runs first.
+// @Summary      Retrieve all command run history.
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=[]CmdHistory} "list of commands and their runs"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/commands/history [get]
+// @Security     ApiKeyAuth
+func (a *Action) HistoryHandler(req *http.Request) (int, interface{}) {
+	output := []*CmdHistory{}
+	hash := mux.Vars(req)["hash"]
+
+	for _, cmd := range a.cmd.cmdlist {
+		if hash != "" && cmd.Hash != hash {
+			continue
+		}
+
+		output = append(output, &CmdHistory{
+			Name:    cmd.Name,
+			Hash:    cmd.Hash,
+			Running: len(cmd.slots),
+			Runs:    cmd.History(),
+		})
+	}
+
+	if hash != "" && len(output) == 0 {
+		return http.StatusBadRequest, ErrUnknownCmd
+	}
+
+	return http.StatusOK, output
+}
@@ -0,0 +1,127 @@
+package commands
+
+/* This file contains the execution queue that limits how many commands run at once. */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrQueueFull is returned when a command cannot run because too many commands are running or waiting.
+var ErrQueueFull = errors.New("command queue is full")
+
+// Queue policies. Queue waits for a free slot, reject fails immediately if no slot is free.
+const (
+	PolicyQueue  = "queue"
+	PolicyReject = "reject"
+)
+
+// Queue defaults.
+const (
+	DefaultConcurrency = 4
+	DefaultMaxQueued   = 20
+	DefaultHistory     = 25
+)
+
+// QueueConfig controls how many commands may run at once, what happens when more are
+// triggered, and how many past runs are kept in each command's history.
+type QueueConfig struct {
+	Concurrency uint   `json:"concurrency" toml:"concurrency" xml:"concurrency" yaml:"concurrency"`
+	MaxQueued   uint   `json:"maxQueued"   toml:"max_queued"  xml:"max_queued"  yaml:"maxQueued"`
+	Policy      string `json:"policy"      toml:"policy"      xml:"policy"      yaml:"policy"`
+	History     uint   `json:"history"     toml:"history"     xml:"history"     yaml:"history"`
+}
+
+// queue is shared by all commands. The running channel is a semaphore for the global concurrency limit.
+type queue struct {
+	*QueueConfig
+	running chan struct{}
+	waiting atomic.Int64
+}
+
+func newQueue(config *QueueConfig) *queue {
+	if config == nil {
+		config = &QueueConfig{}
+	}
+
+	if config.Concurrency == 0 {
+		config.Concurrency = DefaultConcurrency
+	}
+
+	if config.MaxQueued == 0 {
+		config.MaxQueued = DefaultMaxQueued
+	}
+
+	if config.History == 0 {
+		config.History = DefaultHistory
+	}
+
+	if config.Policy != PolicyReject {
+		config.Policy = PolicyQueue
+	}
+
+	return &queue{QueueConfig: config, running: make(chan struct{}, config.Concurrency)}
+}
+
+// acquire a global slot and a per-command slot, and return a function to release them.
+// Commands created outside the action list (ie. GUI tests) have no queue, and are not limited.
+func (q *queue) acquire(ctx context.Context, cmd *Command) (func(), error) {
+	if q == nil || cmd.slots == nil {
+		return func() {}, nil
+	}
+
+	if q.Policy == PolicyReject {
+		return q.tryAcquire(cmd)
+	}
+
+	if waiting := q.waiting.Add(1); waiting > int64(q.MaxQueued) {
+		q.waiting.Add(-1)
+		return nil, fmt.Errorf("%w: %d commands already waiting", ErrQueueFull, waiting-1)
+	}
+	defer q.waiting.Add(-1)
+
+	select {
+	case cmd.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for command slot: %w", ctx.Err())
+	}
+
+	select {
+	case q.running <- struct{}{}:
+	case <-ctx.Done():
+		<-cmd.slots
+		return nil, fmt.Errorf("waiting for queue slot: %w", ctx.Err())
+	}
+
+	return func() { <-q.running; <-cmd.slots }, nil
+}
+
+// tryAcquire is used by the reject policy. It does not wait for a free slot.
+func (q *queue) tryAcquire(cmd *Command) (func(), error) {
+	select {
+	case cmd.slots <- struct{}{}:
+	default:
+		return nil, fmt.Errorf("%w: command already running %d times", ErrQueueFull, cap(cmd.slots))
+	}
+
+	select {
+	case q.running <- struct{}{}:
+	default:
+		<-cmd.slots
+		return nil, fmt.Errorf("%w: %d commands already running", ErrQueueFull, cap(q.running))
+	}
+
+	return func() { <-q.running; <-cmd.slots }, nil
+}
+
+// Queued returns the number of commands waiting to run.
+func (a *Action) Queued() int {
+	return int(a.cmd.queue.waiting.Load())
+}
+
+// Running returns the number of commands running.
+func (a *Action) Running() int {
+	return len(a.cmd.queue.running)
+}
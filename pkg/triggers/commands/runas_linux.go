@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+type runAs = syscall.Credential
+
+// lookupRunAs turns a user, user:group, uid or uid:gid into credentials.
+// If only a user is provided, that user's primary group is used.
+func lookupRunAs(input string) (*runAs, error) {
+	name, group, hasGroup := strings.Cut(input, ":")
+
+	usr, err := user.Lookup(name)
+	if _, ok := err.(user.UnknownUserError); ok { //nolint:errorlint
+		usr, err = user.LookupId(name)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("looking up user: %w", err)
+	}
+
+	gid := usr.Gid
+
+	if hasGroup {
+		grp, err := user.LookupGroup(group)
+		if _, ok := err.(user.UnknownGroupError); ok { //nolint:errorlint
+			grp, err = user.LookupGroupId(group)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("looking up group: %w", err)
+		}
+
+		gid = grp.Gid
+	}
+
+	uidNum, err := strconv.ParseUint(usr.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("parsing uid: %w", err)
+	}
+
+	gidNum, err := strconv.ParseUint(gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("parsing gid: %w", err)
+	}
+
+	return &runAs{Uid: uint32(uidNum), Gid: uint32(gidNum)}, nil
+}
+
+func setRunAs(cmd *exec.Cmd, cred *runAs) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+}
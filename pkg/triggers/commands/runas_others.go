@@ -0,0 +1,19 @@
+//go:build !linux
+
+package commands
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// ErrRunAsLinux is returned when run_as is configured on a non-Linux system.
+var ErrRunAsLinux = errors.New("run_as only works on Linux")
+
+type runAs struct{}
+
+func lookupRunAs(_ string) (*runAs, error) {
+	return nil, ErrRunAsLinux
+}
+
+func setRunAs(_ *exec.Cmd, _ *runAs) {}
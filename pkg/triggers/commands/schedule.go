@@ -0,0 +1,55 @@
+package commands
+
+/* This file contains the procedures that run commands on a local cron schedule. */
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+)
+
+// ErrCatchUp is returned when a command has an invalid catch-up policy.
+var ErrCatchUp = errors.New("invalid catch_up policy, valid: skip, once, all")
+
+// setupSchedule parses the command's cron schedule, if it has one.
+func (c *Command) setupSchedule() error {
+	if c.sched = nil; c.Schedule == "" {
+		return nil
+	}
+
+	sched, err := common.ParseSchedule(c.Schedule, c.Timezone)
+	if err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+
+	switch catchUp := common.CatchUp(strings.ToLower(c.CatchUp)); catchUp {
+	case common.CatchUpSkip, "skip":
+	case common.CatchUpOnce, common.CatchUpAll:
+		sched.CatchUp = catchUp
+	default:
+		return fmt.Errorf("%w: %s", ErrCatchUp, c.CatchUp)
+	}
+
+	sched.Jitter = c.Jitter.Duration
+	sched.Key = "command" + c.Hash
+	c.sched = sched
+
+	return nil
+}
+
+// scheduleAction adds the schedule to the command's action.
+// Disabled commands, and commands that require arguments, are not scheduled.
+func (c *cmd) scheduleAction(cmd *Command, action *common.Action) {
+	switch {
+	case cmd.sched == nil:
+		return
+	case cmd.disable:
+		c.Errorf("Command '%s' is disabled, not scheduling it: %s", cmd.Name, cmd.sched)
+	case len(cmd.args) > 0:
+		c.Errorf("Command '%s' requires %d arguments, scheduled commands cannot have arguments", cmd.Name, len(cmd.args))
+	default:
+		action.S = cmd.sched
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Notifiarr/notifiarr/pkg/apps"
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/commands/cmdconfig"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 )
 
@@ -28,6 +30,7 @@ type Action struct {
 type cmd struct {
 	*common.Config
 	cmdlist []*Command
+	queue   *queue
 }
 
 // Command contains the input data for a defined command.
@@ -42,19 +45,39 @@ type Command struct {
 	output  string // last output logged
 	lastRun time.Time
 	lastArg []string
+	exit    int      // last exit code
+	env     []string // environment with secrets read in.
+	runAs   *runAs
+	apps    *apps.Apps
+	queue   *queue
+	slots   chan struct{} // per-command concurrency limit.
+	sched   *common.Schedule
+	action  *common.Action
 	mu      sync.RWMutex
+	hmu     sync.Mutex // history lock.
 	ch      chan *common.ActionInput
 	log     mnd.Logger
 	website *website.Server
 }
 
 // New configures the library.
-func New(config *common.Config, commands []*Command) *Action {
+func New(config *common.Config, commands []*Command, queueConfig *QueueConfig) *Action {
+	queue := newQueue(queueConfig)
+
 	for _, cmd := range commands {
-		cmd.Setup(config.Logger, config.Server)
+		if err := cmd.Setup(config.Logger, config.Server); err != nil {
+			config.Errorf("Command Setup Failed: %v", err)
+			cmd.disable = true //nolint:wsl
+		}
+
+		cmd.apps = config.Apps
+		cmd.queue = queue
+		cmd.slots = make(chan struct{}, max(cmd.Limit, 1))
+		// The hash is the key suffix, so each command registers its own history key.
+		data.Persistent[[]*Run](historyKey + cmd.Hash)
 	}
 
-	return &Action{cmd: &cmd{Config: config, cmdlist: commands}}
+	return &Action{cmd: &cmd{Config: config, cmdlist: commands, queue: queue}}
 }
 
 // Run fires a custom command.
@@ -103,6 +126,12 @@ type Stats struct {
 	LastOutput string           `json:"output"`
 	LastRun    string           `json:"last"`
 	LastArgs   []string         `json:"lastArgs"`
+	LastExit   int              `json:"lastExit"`
+	Running    int              `json:"running"`
+	History    []*Run           `json:"history"`
+	Arguments  []*ArgInfo       `json:"arguments"`
+	Schedule   string           `json:"schedule,omitempty"`
+	NextRun    string           `json:"nextRun,omitempty"`
 }
 
 // Stats returns statistics about a command.
@@ -123,9 +152,24 @@ func (c *Command) Stats() Stats {
 		LastOutput: c.output,
 		LastRun:    last,
 		LastArgs:   c.lastArg,
+		LastExit:   c.exit,
+		Running:    len(c.slots),
+		History:    c.History(),
+		Arguments:  c.ArgInfo(),
+		Schedule:   c.Schedule,
+		NextRun:    c.nextRun(),
 	}
 }
 
+// nextRun returns the next scheduled run time, or an empty string if the command is not scheduled.
+func (c *Command) nextRun() string {
+	if c.action == nil || c.action.Next().IsZero() {
+		return ""
+	}
+
+	return c.action.Next().Round(time.Second).String()
+}
+
 func (c *cmd) create() {
 	for _, cmd := range c.cmdlist {
 		if err := cmd.SetupRegexpArgs(); err != nil {
@@ -134,13 +178,16 @@ func (c *cmd) create() {
 		}
 
 		cmd.ch = make(chan *common.ActionInput, 1)
-
-		c.Add(&common.Action{
+		cmd.action = &common.Action{
 			Name: common.TriggerName(fmt.Sprintf("Running Custom Command '%s'", cmd.Name)),
 			Fn:   cmd.run,
 			C:    cmd.ch,
-		})
+		}
+
+		c.scheduleAction(cmd, cmd.action)
+		c.Add(cmd.action)
 	}
 
-	c.Printf("==> Custom Commands: %d provided", len(c.cmdlist))
+	c.Printf("==> Custom Commands: %d provided, concurrency: %d, max queued: %d, policy: %s",
+		len(c.cmdlist), c.queue.Concurrency, c.queue.MaxQueued, c.queue.Policy)
 }
@@ -0,0 +1,54 @@
+package commands
+
+/* This file contains the procedures used to stream command output line by line. */
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// lineWriter calls fn with each complete line written to it.
+type lineWriter struct {
+	buf []byte
+	fn  func(string)
+}
+
+// Write satisfies the io.Writer interface.
+func (l *lineWriter) Write(data []byte) (int, error) {
+	l.buf = append(l.buf, data...)
+
+	for {
+		idx := bytes.IndexByte(l.buf, '\n')
+		if idx < 0 {
+			return len(data), nil
+		}
+
+		l.fn(strings.TrimSuffix(string(l.buf[:idx]), "\r"))
+		l.buf = l.buf[idx+1:]
+	}
+}
+
+// flush sends any incomplete last line.
+func (l *lineWriter) flush() {
+	if len(l.buf) > 0 {
+		l.fn(string(l.buf))
+		l.buf = nil
+	}
+}
+
+// ExitCode returns the exit code from a command's error.
+// Returns 0 for no error, and -1 if the error did not come from the exited process.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
@@ -0,0 +1,109 @@
+package common
+
+/* This file contains the cron schedules for actions, and the logic to find missed runs. */
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ErrSchedule is returned when a cron expression cannot be parsed.
+var ErrSchedule = errors.New("invalid schedule")
+
+// CatchUp is the policy for scheduled runs that were missed while the app was not running.
+type CatchUp string
+
+// These are the supported catch-up policies.
+const (
+	CatchUpSkip CatchUp = ""     // Do not run missed schedules.
+	CatchUpOnce CatchUp = "once" // Run once at startup if any schedule was missed.
+	CatchUpAll  CatchUp = "all"  // Run once for every missed schedule, up to maxCatchUp times.
+)
+
+// maxCatchUp is the most missed runs that will be executed for the all catch-up policy.
+const maxCatchUp = 10
+
+// yearsToSearch is how far ahead the cron library looks for a matching time.
+const yearsToSearch = 5
+
+// Schedule fires an action on a cron schedule. Create one with ParseSchedule.
+type Schedule struct {
+	Jitter  time.Duration // A random delay, up to this much, is added to each run.
+	CatchUp CatchUp       // What to do with runs missed while the app was not running.
+	Key     string        // Saves the last run time with this key. Defaults to the action name.
+	expr    string
+	loc     *time.Location
+	spec    cron.Schedule
+}
+
+// ParseSchedule parses a standard 5-field cron expression (minute hour day-of-month month day-of-week),
+// or one of the @yearly, @monthly, @weekly, @daily, @hourly or @every macros. The timezone is an IANA
+// zone name, and an empty timezone uses the local time zone.
+func ParseSchedule(expr, timezone string) (*Schedule, error) {
+	sched := &Schedule{expr: strings.TrimSpace(expr), loc: time.Local}
+
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: timezone: %w", ErrSchedule, err)
+		}
+
+		sched.loc = loc
+	}
+
+	spec := sched.expr
+	if strings.HasPrefix(spec, "@") { // the library only knows lowercase macros.
+		spec = strings.ToLower(spec)
+	}
+
+	var err error
+	if sched.spec, err = cron.ParseStandard(spec); err != nil {
+		return nil, fmt.Errorf("%w: '%s': %w", ErrSchedule, expr, err)
+	}
+
+	if sched.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: '%s' never fires", ErrSchedule, expr)
+	}
+
+	return sched, nil
+}
+
+// String returns the original expression.
+func (s *Schedule) String() string {
+	if s == nil {
+		return ""
+	}
+
+	if s.loc != time.Local {
+		return s.expr + " (" + s.loc.String() + ")"
+	}
+
+	return s.expr
+}
+
+// Next returns the next time the schedule fires after the provided time.
+// Jitter is not included. Returns a zero time if the schedule never fires (ie. Feb 30).
+func (s *Schedule) Next(after time.Time) time.Time {
+	// Schedules without a time zone in the expression use the zone of the time they're given.
+	next := s.spec.Next(after.In(s.loc))
+	if next.IsZero() {
+		return next
+	}
+
+	return next.In(after.Location())
+}
+
+// Missed returns how many times the schedule fired between the two times, up to maxCatchUp.
+func (s *Schedule) Missed(since, until time.Time) int {
+	count := 0
+
+	for next := s.Next(since); !next.IsZero() && !next.After(until) && count < maxCatchUp; next = s.Next(next) {
+		count++
+	}
+
+	return count
+}
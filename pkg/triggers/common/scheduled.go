@@ -0,0 +1,209 @@
+package common
+
+/* This file contains the procedures that run actions on a cron schedule, and catch up on missed runs. */
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+)
+
+// lastRunKey is the data cache key for the last run times of scheduled actions.
+const lastRunKey = "scheduleLastRuns"
+
+// lastRunLock protects the last run times while they're updated and written to disk.
+//
+//nolint:gochecknoglobals
+var lastRunLock sync.Mutex
+
+// ScheduleInfo is the schedule and next run time for a scheduled action.
+type ScheduleInfo struct {
+	Schedule string
+	Next     time.Time
+}
+
+// schedule sets the next run time for a scheduled action and returns how long until then, jitter included.
+func (a *Action) schedule(now time.Time) time.Duration {
+	next := a.S.Next(now)
+	if next.IsZero() { // this should not happen because ParseSchedule checks for it.
+		next = now.AddDate(yearsToSearch, 0, 0)
+	}
+
+	if a.S.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(a.S.Jitter)))) //nolint:gosec
+	}
+
+	a.next.Store(next.UnixNano())
+
+	return next.Sub(now)
+}
+
+// Next returns the next time a scheduled action runs, or a zero time if it's not scheduled.
+func (a *Action) Next() time.Time {
+	if next := a.next.Load(); next != 0 {
+		return time.Unix(0, next)
+	}
+
+	return time.Time{}
+}
+
+// key is used to save the last run time for a scheduled action.
+func (a *Action) key() string {
+	if a.S.Key != "" {
+		return a.S.Key
+	}
+
+	return string(a.Name)
+}
+
+// Scheduled returns the schedule and next run time for every scheduled action, by action name.
+func (c *Config) Scheduled() map[string]*ScheduleInfo {
+	scheduled := make(map[string]*ScheduleInfo)
+
+	for _, action := range c.list {
+		if action != nil && action.cron != nil {
+			scheduled[string(action.Name)] = &ScheduleInfo{Schedule: action.S.String(), Next: action.Next()}
+		}
+	}
+
+	return scheduled
+}
+
+// runSchedule is called by the timer loop when a scheduled action fires.
+// It saves the last run time, and resets the timer for the next run.
+func (c *Config) runSchedule(action *Action) {
+	now := time.Now()
+	c.saveLastRun(action.key(), now)
+	action.cron.Reset(action.schedule(now))
+}
+
+// catchUp queues scheduled actions that were missed while the app was not running.
+// This runs in its own go routine when the timer loop starts, so startup does not wait on it.
+// The missed runs are sent through each action's channel, so they run in the timer loop like any other trigger.
+func (c *Config) catchUp(ctx context.Context) {
+	defer c.CapturePanic()
+
+	lastRuns := c.lastRuns()
+	now := time.Now()
+	stopCtx := c.StopContext()
+
+	for _, action := range c.list {
+		if action == nil || action.cron == nil || action.Fn == nil || action.C == nil {
+			continue
+		}
+
+		last, ok := lastRuns[action.key()]
+		if !ok {
+			// Never ran, start tracking it now so missed runs can be found later.
+			c.saveLastRun(action.key(), now)
+			continue
+		}
+
+		missed := action.S.Missed(last, now)
+		if missed == 0 {
+			continue
+		}
+
+		c.Printf("[%s requested] Scheduled action missed %d run(s) since %s: %s (catch up: %s)",
+			website.EventCron, missed, last.Round(time.Second), action.Name, action.S.CatchUp)
+
+		switch action.S.CatchUp {
+		case CatchUpSkip:
+			missed = 0
+		case CatchUpOnce:
+			missed = 1
+		case CatchUpAll:
+		}
+
+		c.saveLastRun(action.key(), now)
+
+		for range missed {
+			if err := c.send(ctx, stopCtx, &ActionInput{Type: website.EventCron}, action.Name); err != nil {
+				c.Errorf("[%s requested] Catching up scheduled action: %s: %v", website.EventCron, action.Name, err)
+				return // the loop stopped.
+			}
+		}
+	}
+}
+
+// lastRuns returns the last run times for scheduled actions.
+// They're kept in the data cache, so they survive reloads, and read from the state file at startup.
+func (c *Config) lastRuns() map[string]time.Time {
+	lastRunLock.Lock()
+	defer lastRunLock.Unlock()
+
+	return c.loadLastRuns()
+}
+
+// loadLastRuns must be called while holding the last run lock.
+func (c *Config) loadLastRuns() map[string]time.Time {
+	if item := data.Get(lastRunKey); item != nil {
+		if runs, ok := item.Data.(map[string]time.Time); ok {
+			return runs
+		}
+	}
+
+	runs := make(map[string]time.Time)
+
+	if c.StateFile == "" {
+		return runs
+	}
+
+	if content, err := os.ReadFile(c.StateFile); err == nil {
+		if err := json.Unmarshal(content, &runs); err != nil {
+			c.Errorf("Reading schedule state file %s: %v", c.StateFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		c.Errorf("Reading schedule state file: %v", err)
+	}
+
+	data.Save(lastRunKey, runs)
+
+	return runs
+}
+
+// saveLastRun updates the last run time for a scheduled action, and writes the state file.
+// The saved map is not changed, because the data store API may be encoding it; a copy is saved.
+func (c *Config) saveLastRun(key string, when time.Time) {
+	lastRunLock.Lock()
+	defer lastRunLock.Unlock()
+
+	runs := maps.Clone(c.loadLastRuns())
+	runs[key] = when
+	data.Save(lastRunKey, runs)
+
+	if c.StateFile == "" {
+		return
+	}
+
+	if err := writeStateFile(c.StateFile, runs); err != nil {
+		c.Errorf("Writing schedule state file: %v", err)
+	}
+}
+
+// writeStateFile writes to a temporary file first, so a crash cannot leave a partial file.
+func writeStateFile(path string, runs map[string]time.Time) error {
+	content, err := json.MarshalIndent(runs, "", " ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	if err := os.WriteFile(path+".tmp", content, mnd.Mode0600); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("renaming state file: %w", err)
+	}
+
+	return nil
+}
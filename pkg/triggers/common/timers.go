@@ -22,6 +22,7 @@ func (c *Config) Run(ctx context.Context) {
 	}
 
 	c.stop = &Action{Name: TrigStop, C: make(chan *ActionInput)}
+	c.stopCtx, c.stopNow = context.WithCancel(ctx)
 
 	var (
 		cases   = []reflect.SelectCase{}
@@ -43,6 +44,11 @@ func (c *Config) Run(ctx context.Context) {
 			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(action.t.C)})
 			combine = append(combine, action)
 		}
+
+		if action.cron != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(action.cron.C)})
+			combine = append(combine, action)
+		}
 	}
 
 	go c.runTimerLoop(ctx, combine, cases)
@@ -89,6 +95,10 @@ func (c *Config) printStartupLog() {
 			c.Debugf("==> Enabled Action: %s Timer only, interval: %s", name, dur)
 		}
 	}
+
+	for name, info := range c.Scheduled() {
+		c.Debugf("==> Enabled Action: %s Schedule: %s, next run: %s", name, info.Schedule, info.Next.Round(time.Second))
+	}
 }
 
 // runTimerLoop does all of the timer/cron routines for starr apps and plex.
@@ -102,6 +112,8 @@ func (c *Config) runTimerLoop(ctx context.Context, actions []*Action, cases []re
 		c.stopTimerLoop(actions)
 	}()
 
+	go c.catchUp(ctx)
+
 	// This is how you watch a slice of reflect.SelectCase.
 	// This allows watching a dynamic amount of channels and tickers.
 	for {
@@ -112,6 +124,10 @@ func (c *Config) runTimerLoop(ctx context.Context, actions []*Action, cases []re
 
 		if _, ok := val.Interface().(time.Time); ok {
 			input.Type = website.EventCron
+
+			if action.cron != nil {
+				c.runSchedule(action)
+			}
 		} else if input, ok = val.Interface().(*ActionInput); !ok {
 			input.Type = "unknown"
 		}
@@ -128,6 +144,10 @@ func (c *Config) runTimerLoop(ctx context.Context, actions []*Action, cases []re
 }
 
 func (c *Config) runEventAction(ctx context.Context, input *ActionInput, action *Action) {
+	if input.done != nil {
+		defer close(input.done)
+	}
+
 	if input.Type == website.EventUser && action.Name != "" {
 		if err := ui.Toast(string(action.Name)); err != nil {
 			c.Errorf("Displaying toast notification: %v", err)
@@ -151,12 +171,23 @@ func (c *Config) stopTimerLoop(actions []*Action) {
 
 	c.Printf("!!> Stopping main Notifiarr loop. All timers and triggers are now disabled.")
 
+	// Cancel first, so ExecWait gives up on a blocked send and releases the lock.
+	c.stopNow()
+	c.sendLock.Lock()
+	defer c.sendLock.Unlock()
+
 	for _, action := range actions {
 		if action.t != nil {
 			action.t.Stop()
 			action.t = nil
 		}
 
+		if action.cron != nil {
+			action.cron.Stop()
+			action.cron = nil
+			action.next.Store(0)
+		}
+
 		if action.C != nil && action.C != c.stop.C { // do not close stop channel here.
 			close(action.C)
 			action.C = nil
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/apps"
@@ -23,6 +25,9 @@ var ErrInvalidApp = errors.New("invalid application provided")
 // ErrNoChannel is returned when the go routine is stopped.
 var ErrNoChannel = errors.New("no channel to send request")
 
+// ErrNoTrigger is returned when a trigger cannot be found, or does not accept input.
+var ErrNoTrigger = errors.New("trigger not found")
+
 // Config is the input data shared by most triggers.
 // Everything is mandatory.
 type Config struct {
@@ -37,6 +42,11 @@ type Config struct {
 	reloadCh chan os.Signal // so triggers can reload the app.
 	stopCh   chan os.Signal // so triggers can stop the app.
 	rand     *rand.Rand
+	// StateFile is where scheduled actions save their last run times. Optional.
+	StateFile string
+	stopCtx   context.Context    // canceled when the timer loop stops.
+	stopNow   context.CancelFunc // cancels stopCtx.
+	sendLock  sync.RWMutex       // held by ExecWait while sending, so the loop does not close the channel under it.
 }
 
 type Create interface {
@@ -88,6 +98,7 @@ func (c *Config) StopApp(reason string) {
 type ActionInput struct {
 	Type website.EventType
 	Args []string
+	done chan struct{} // closed when the action finishes, used by ExecWait.
 }
 
 // TriggerName makes sure triggers have a known name.
@@ -100,6 +111,9 @@ type Action struct {
 	Fn   func(context.Context, *ActionInput) // most actions use this for triggers.
 	C    chan *ActionInput                   // if provided, D is optional.
 	t    *time.Ticker                        // if provided, C is optional.
+	S    *Schedule                           // if provided, D is ignored and the action fires on a cron schedule.
+	cron *time.Timer                         // fires at the next scheduled time, set from S.
+	next atomic.Int64                        // next scheduled time in unix nanoseconds.
 	Hide bool                                // prevent logging.
 }
 
@@ -120,6 +134,57 @@ func (c *Config) Exec(input *ActionInput, name TriggerName) bool {
 	return true
 }
 
+// ExecWait runs a trigger and waits for it to finish, the context to end, or the timer loop to stop.
+// Actions that start their own go routines may finish before their work is done.
+func (c *Config) ExecWait(ctx context.Context, input *ActionInput, name TriggerName) error {
+	stopCtx := c.StopContext()
+	input.done = make(chan struct{})
+
+	if err := c.send(ctx, stopCtx, input, name); err != nil {
+		return err
+	}
+
+	select {
+	case <-input.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for trigger: %w", ctx.Err())
+	case <-stopCtx.Done():
+		return fmt.Errorf("waiting for trigger: %w", ErrNoChannel)
+	}
+}
+
+// send holds the send lock, so the timer loop cannot close the trigger channel while this sends on it.
+// The loop cancels stopCtx before it takes the lock, so a blocked send gives up.
+func (c *Config) send(ctx, stopCtx context.Context, input *ActionInput, name TriggerName) error {
+	c.sendLock.RLock()
+	defer c.sendLock.RUnlock()
+
+	trig := c.Get(name)
+	if stopCtx.Err() != nil || c.stop == nil || trig == nil || trig.C == nil {
+		return fmt.Errorf("%w: %s", ErrNoTrigger, name)
+	}
+
+	select {
+	case trig.C <- input:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("sending trigger: %w", ctx.Err())
+	case <-stopCtx.Done():
+		return fmt.Errorf("sending trigger: %w", ErrNoChannel)
+	}
+}
+
+// StopContext returns a context that is canceled when the timer loop stops.
+// Use it for go routines started by triggers, so they end on reload.
+func (c *Config) StopContext() context.Context {
+	if c.stopCtx == nil {
+		return context.Background()
+	}
+
+	return c.stopCtx
+}
+
 // Get a trigger by unique name. May return nil, and that could cause a panic.
 // We avoid panics by using a custom type with corresponding constants as input.
 func (c *Config) Get(name TriggerName) *Action {
@@ -136,7 +201,9 @@ func (c *Config) Get(name TriggerName) *Action {
 // actions are timers or triggers, or both.
 func (c *Config) Add(action ...*Action) {
 	for _, a := range action {
-		if a.D.Duration != 0 {
+		if a.S != nil {
+			a.cron = time.NewTimer(a.schedule(time.Now()))
+		} else if a.D.Duration != 0 {
 			a.t = time.NewTicker(a.D.Duration)
 		}
 	}
@@ -52,6 +52,7 @@ type Timer struct {
 	*clientinfo.CronConfig
 	website *website.Server
 	ch      chan *common.ActionInput
+	action  *common.Action
 }
 
 // New configures the library.
@@ -138,22 +139,32 @@ func (c *cmd) create() {
 			website:    c.Config.Server,
 		}
 		custom.URI = "/" + strings.TrimPrefix(custom.URI, "/")
+		timer.action = &common.Action{
+			Name: common.TriggerName(fmt.Sprintf("Running Custom Cron Timer '%s'", custom.Name)),
+			Fn:   timer.run,
+			C:    timer.ch,
+		}
+
+		if sched, err := timer.schedule(); err != nil {
+			c.ErrorfNoShare("Website provided invalid custom cron schedule, using interval. %v, Name: %s, URI: %s",
+				err, custom.Name, custom.URI)
+		} else if sched != nil {
+			timer.action.S = sched
+		}
 
-		if custom.Interval.Duration < time.Minute {
-			c.ErrorfNoShare("Website provided custom cron interval under 1 minute. Interval: %s Name: %s, URI: %s",
-				custom.Interval, custom.Name, custom.URI)
+		if timer.action.S == nil {
+			if custom.Interval.Duration < time.Minute {
+				c.ErrorfNoShare("Website provided custom cron interval under 1 minute. Interval: %s Name: %s, URI: %s",
+					custom.Interval, custom.Name, custom.URI)
 
-			custom.Interval.Duration = time.Minute
+				custom.Interval.Duration = time.Minute
+			}
+
+			timer.action.D = cnfg.Duration{Duration: custom.Interval.Duration}
 		}
 
 		c.list = append(c.list, timer)
-
-		c.Add(&common.Action{
-			Name: common.TriggerName(fmt.Sprintf("Running Custom Cron Timer '%s'", custom.Name)),
-			Fn:   timer.run,
-			C:    timer.ch,
-			D:    cnfg.Duration{Duration: custom.Interval.Duration},
-		})
+		c.Add(timer.action)
 	}
 
 	c.Printf("==> Custom Timers Enabled: %d timers provided", len(info.Actions.Custom))
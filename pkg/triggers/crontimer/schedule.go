@@ -0,0 +1,56 @@
+package crontimer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+)
+
+// schedule returns the timer's cron schedule, or nil if the timer uses an interval.
+// A time of day, like 03:00, is turned into a daily cron expression.
+// Timers catch up once at startup if they were missed while the app was down.
+func (t *Timer) schedule() (*common.Schedule, error) {
+	expr := t.Cron
+	if expr == "" && t.AtTime != "" {
+		at, err := time.Parse("15:04", t.AtTime)
+		if err != nil {
+			return nil, fmt.Errorf("%w: time of day '%s' must be HH:MM", common.ErrSchedule, t.AtTime)
+		}
+
+		expr = fmt.Sprintf("%d %d * * *", at.Minute(), at.Hour())
+	}
+
+	if expr == "" {
+		return nil, nil //nolint:nilnil // no schedule is not an error.
+	}
+
+	sched, err := common.ParseSchedule(expr, t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("custom timer schedule: %w", err)
+	}
+
+	sched.CatchUp = common.CatchUpOnce
+	// Timers may share a URI, so the name is part of the key.
+	sched.Key = "customTimer" + t.Name + t.URI
+
+	return sched, nil
+}
+
+// Schedule returns the timer's cron schedule, or an empty string if it runs on an interval.
+func (t *Timer) Schedule() string {
+	if t.action == nil {
+		return ""
+	}
+
+	return t.action.S.String()
+}
+
+// Next returns the next time a scheduled timer runs, or a zero time if it runs on an interval.
+func (t *Timer) Next() time.Time {
+	if t.action == nil {
+		return time.Time{}
+	}
+
+	return t.action.Next()
+}
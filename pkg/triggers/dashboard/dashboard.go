@@ -8,6 +8,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/plexcron"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/starrqueue"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
 	"golift.io/cnfg"
@@ -22,7 +23,9 @@ const randomMilliseconds = 2500
 
 type Cmd struct {
 	*common.Config
-	PlexCron *plexcron.Action
+	PlexCron   *plexcron.Action
+	StarrQueue *starrqueue.Action
+	samples    samples
 }
 
 // Action contains the exported methods for this package.
@@ -63,6 +66,11 @@ type State struct {
 	OnDisk   int64         `json:"onDisk,omitempty"`
 	Elapsed  cnfg.Duration `json:"elapsed"` // How long it took.
 	Name     string        `json:"name"`
+	// Starr apps: root folder space, and import failures. Lidarr and Readarr count failed imports
+	// in the last day from history. Sonarr and Radarr count queue items with a blocked import.
+	RootFolders    []*RootFolder `json:"rootFolders,omitempty"`
+	ImportFailures int64         `json:"importFailures,omitempty"`
+	Warning        string        `json:"warning,omitempty"`
 	// Radarr
 	Movies int64 `json:"movies,omitempty"`
 	// Sonarr
@@ -89,6 +97,8 @@ type State struct {
 	Month       int64 `json:"month,omitempty"`
 	Week        int64 `json:"week,omitempty"`
 	Day         int64 `json:"day,omitempty"`
+	// History is sampled rates and queue sizes since the last send.
+	History *History `json:"history,omitempty"`
 }
 
 // States is our compiled states for the dashboard.
@@ -104,14 +114,19 @@ type States struct {
 	SabNZB   []*State `json:"sabnzbd"`
 	Xmission []*State `json:"transmission"`
 	Plex     any      `json:"plexSessions"`
+	// Queues are success rates and completion times computed from the starr queue history.
+	Queues []*starrqueue.Analytics `json:"queueAnalytics"`
 }
 
 // New configures the library.
-func New(config *common.Config, plex *plexcron.Action) *Action {
+func New(config *common.Config, plex *plexcron.Action, queue *starrqueue.Action) *Action {
+	data.Persistent[*States]("dashboard")
+
 	return &Action{
 		cmd: &Cmd{
-			Config:   config,
-			PlexCron: plex,
+			Config:     config,
+			PlexCron:   plex,
+			StarrQueue: queue,
 		},
 	}
 }
@@ -137,6 +152,11 @@ func (c *Cmd) create() {
 		C:    make(chan *common.ActionInput, 1),
 		D:    cnfg.Duration{Duration: dur},
 	})
+
+	// The sampler's stop channel is made here, and not in Run, so Stop works before Run starts.
+	c.samples.Lock()
+	c.samples.stop = make(chan struct{})
+	c.samples.Unlock()
 }
 
 // Send the current states for the dashboard to the website.
@@ -151,7 +171,15 @@ func (c *Cmd) sendDashboardState(ctx context.Context, input *common.ActionInput)
 		apps   = time.Since(start).Round(time.Millisecond)
 	)
 
+	collect := len(input.Args) > 0 && input.Args[0] == collectOnly
+	c.addHistory(states, !collect)
 	data.Save("dashboard", states)
+
+	if collect {
+		c.Debugf("[%s requested] Collected Dashboard State (elapsed: %v)", input.Type, apps)
+		return
+	}
+
 	c.SendData(&website.Request{
 		Route:      website.DashRoute,
 		Event:      input.Type,
@@ -177,6 +205,7 @@ func (c *Cmd) getStates(ctx context.Context) *States {
 		SabNZB:   c.getSabNZBStates(ctx),
 		Xmission: c.getTransmissionStates(ctx),
 		Plex:     sessions,
+		Queues:   c.StarrQueue.Analytics(),
 	}
 }
 
@@ -0,0 +1,129 @@
+package dashboard
+
+/* This file contains the API handler that returns the dashboard states collected locally. */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"github.com/gorilla/mux"
+)
+
+// Errors returned by the dashboard handler.
+var (
+	ErrNoStates   = errors.New("dashboard states have not been collected yet, try refresh=true")
+	ErrUnknownApp = errors.New("unknown dashboard app")
+	ErrNoInstance = errors.New("dashboard app instance not found")
+)
+
+// collectOnly is passed as an argument to the dashboard trigger to skip sending the states to the website.
+const collectOnly = "collect-only"
+
+// refreshTimeout is how long the API waits for a synchronous refresh.
+const refreshTimeout = 2 * time.Minute
+
+// States returns the last collected dashboard states, or nil if they have not been collected.
+// Set refresh to collect them again first; this waits for the collection to finish.
+func (a *Action) States(ctx context.Context, refresh bool) (*States, error) {
+	if refresh {
+		input := &common.ActionInput{Type: website.EventAPI, Args: []string{collectOnly}}
+		if err := a.cmd.ExecWait(ctx, input, TrigDashboard); err != nil {
+			return nil, fmt.Errorf("refreshing dashboard: %w", err)
+		}
+	}
+
+	if item := data.Get("dashboard"); item != nil {
+		if states, ok := item.Data.(*States); ok {
+			return states, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+// app returns the states for one app by its json name, ie. sonarr or transmission.
+func (s *States) app(name string) ([]*State, bool) {
+	apps := map[string][]*State{
+		"lidarr":       s.Lidarr,
+		"radarr":       s.Radarr,
+		"readarr":      s.Readarr,
+		"sonarr":       s.Sonarr,
+		"nzbget":       s.NZBGet,
+		"rtorrent":     s.RTorrent,
+		"qbit":         s.Qbit,
+		"deluge":       s.Deluge,
+		"sabnzbd":      s.SabNZB,
+		"transmission": s.Xmission,
+	}
+	states, ok := apps[name]
+
+	return states, ok
+}
+
+// @Description  Returns the dashboard states for one app: lidarr, radarr, readarr, sonarr,
+// @Description  nzbget, rtorrent, qbit, deluge, sabnzbd or transmission. Provide an instance to get only one.
+// @Summary      Retrieve dashboard states for an app.
+// @Tags         Triggers
+// @Produce      json
+// @Param        app       path   string  true  "app name"
+// @Param        instance  path   int64   false "instance ID"
+// @Param        refresh   query  bool    false "collect the states again before returning them"
+// @Success      200  {object} apps.Respond.apiResponse{message=[]State} "states for the app"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "no states, unknown app, or bad token or api key"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "refresh failed"
+// @Router       /api/dashboard/{app}/{instance} [get]
+// @Security     ApiKeyAuth
+func _() {}
+
+// Handler returns the dashboard states for every app, one app, or one app instance.
+// @Description  Returns the dashboard states last collected for every app. These are the same states
+// @Description  sent to the website. Set refresh to true to collect them again, and wait for that to finish.
+// @Summary      Retrieve dashboard states.
+// @Tags         Triggers
+// @Produce      json
+// @Param        refresh   query  bool    false "collect the states again before returning them"
+// @Success      200  {object} apps.Respond.apiResponse{message=States} "states for every app"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "no states, or bad token or api key"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "refresh failed"
+// @Router       /api/dashboard [get]
+// @Security     ApiKeyAuth
+func (a *Action) Handler(req *http.Request) (int, interface{}) {
+	refresh, _ := strconv.ParseBool(req.URL.Query().Get("refresh"))
+	ctx, cancel := context.WithTimeout(req.Context(), refreshTimeout)
+	defer cancel()
+
+	states, err := a.States(ctx, refresh)
+	if err != nil {
+		return http.StatusServiceUnavailable, err
+	} else if states == nil {
+		return http.StatusNotFound, ErrNoStates
+	}
+
+	vars := mux.Vars(req)
+	if vars["app"] == "" {
+		return http.StatusOK, states
+	}
+
+	appStates, ok := states.app(vars["app"])
+	if !ok {
+		return http.StatusNotFound, fmt.Errorf("%w: %s", ErrUnknownApp, vars["app"])
+	} else if vars["instance"] == "" {
+		return http.StatusOK, appStates
+	}
+
+	instance, _ := strconv.Atoi(vars["instance"])
+	for _, state := range appStates {
+		if state.Instance == instance {
+			return http.StatusOK, state
+		}
+	}
+
+	return http.StatusNotFound, fmt.Errorf("%w: %s %d", ErrNoInstance, vars["app"], instance)
+}
@@ -84,6 +84,10 @@ func (c *Cmd) getLidarrState(ctx context.Context, instance int, app *apps.Lidarr
 		return state, fmt.Errorf("instance %d: %w", instance, err)
 	}
 
+	if err = c.getLidarrStorage(ctx, state, app); err != nil {
+		return state, fmt.Errorf("instance %d: %w", instance, err)
+	}
+
 	return state, nil
 }
 
@@ -49,6 +49,10 @@ func (c *Cmd) getRadarrState(ctx context.Context, instance int, r *apps.RadarrCo
 	state.Latest.Shrink(showLatest)
 	state.Next.Shrink(showNext)
 
+	if err = c.getRadarrStorage(ctx, state, r); err != nil {
+		return state, fmt.Errorf("instance %d: %w", instance, err)
+	}
+
 	return state, nil
 }
 
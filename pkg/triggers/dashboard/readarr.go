@@ -84,6 +84,10 @@ func (c *Cmd) getReadarrState(ctx context.Context, instance int, app *apps.Reada
 		return state, fmt.Errorf("instance %d: %w", instance, err)
 	}
 
+	if err = c.getReadarrStorage(ctx, state, app); err != nil {
+		return state, fmt.Errorf("instance %d: %w", instance, err)
+	}
+
 	return state, nil
 }
 
@@ -0,0 +1,350 @@
+package dashboard
+
+/* This file samples downloader speeds and queue sizes between dashboard sends. */
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
+	"golift.io/cnfg"
+)
+
+const (
+	// sampleInterval is how often the downloaders are sampled.
+	sampleInterval = 30 * time.Second
+	// maxSamples is how many samples are kept for each downloader, if the dashboard is not sent for a while.
+	maxSamples = 240
+	// sparkPoints is the most points in a sparkline series. Samples are averaged into buckets to fit.
+	sparkPoints = 30
+)
+
+// sample is a point-in-time reading from a downloader.
+type sample struct {
+	download int64 // bytes per second.
+	upload   int64 // bytes per second.
+	queue    int64 // items in the queue.
+}
+
+// samples are the readings for every downloader instance since the dashboard was last sent.
+type samples struct {
+	sync.Mutex
+	since  time.Time
+	values map[string][]*sample // key is app name and instance, ie. qbit1.
+	stop   chan struct{}
+}
+
+// Series is the summary of a sampled value.
+type Series struct {
+	Min   int64   `json:"min"`
+	Avg   int64   `json:"avg"`
+	Max   int64   `json:"max"`
+	Spark []int64 `json:"spark"` // averaged samples, oldest first.
+}
+
+// History is the sampled download and upload rates, and queue sizes, for a downloader since the last dashboard send.
+type History struct {
+	Since    time.Time     `json:"since"`
+	Interval cnfg.Duration `json:"interval"` // between samples.
+	Samples  int           `json:"samples"`
+	Download *Series       `json:"download"` // bytes per second.
+	Upload   *Series       `json:"upload"`   // bytes per second.
+	Queue    *Series       `json:"queue"`
+}
+
+// Run starts the downloader sampler. It does not run if there are no downloaders configured,
+// or if the website did not enable the dashboard timer, because the samples are only sent with it.
+func (a *Action) Run(ctx context.Context) {
+	if ci := clientinfo.Get(); ci == nil || ci.Actions.Dashboard.Interval.Duration == 0 || !a.cmd.hasDownloaders() {
+		return
+	}
+
+	a.cmd.samples.Lock()
+	a.cmd.samples.since = time.Now()
+	stop := a.cmd.samples.stop // made in Create, so a Stop before Run still ends the sampler.
+	a.cmd.samples.Unlock()
+
+	if stop == nil {
+		return // stopped before it started.
+	}
+
+	a.cmd.Printf("==> Dashboard downloader sampler started, interval: %s", sampleInterval)
+
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.cmd.sampleDownloaders(ctx)
+		}
+	}
+}
+
+// Stop the downloader sampler.
+func (a *Action) Stop() {
+	a.cmd.samples.Lock()
+	defer a.cmd.samples.Unlock()
+
+	if a.cmd.samples.stop != nil {
+		close(a.cmd.samples.stop)
+		a.cmd.samples.stop = nil
+	}
+}
+
+func (c *Cmd) hasDownloaders() bool {
+	return len(c.Apps.Deluge)+len(c.Apps.Qbit)+len(c.Apps.Rtorrent)+
+		len(c.Apps.Transmission)+len(c.Apps.SabNZB)+len(c.Apps.NZBGet) > 0
+}
+
+// sampleDownloaders reads the rates and queue size from every downloader, using the same clients as the dashboard.
+// A downloader that returns an error is skipped; the dashboard send reports the error.
+func (c *Cmd) sampleDownloaders(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, sampleInterval)
+	defer cancel()
+
+	readings := make(map[string]*sample)
+	add := func(app string, instance int, reading *sample, err error) {
+		if err != nil {
+			c.Debugf("Sampling %s %d: %v", app, instance+1, err)
+		} else {
+			readings[fmt.Sprint(app, instance+1)] = reading
+		}
+	}
+
+	for idx, app := range c.Apps.Deluge {
+		if app.Enabled() {
+			reading, err := sampleDeluge(ctx, app)
+			add("deluge", idx, reading, err)
+		}
+	}
+
+	for idx, app := range c.Apps.Qbit {
+		if app.Enabled() {
+			reading, err := sampleQbit(ctx, app)
+			add("qbit", idx, reading, err)
+		}
+	}
+
+	for idx, app := range c.Apps.Rtorrent {
+		if app.Enabled() {
+			reading, err := sampleRtorrent(app)
+			add("rtorrent", idx, reading, err)
+		}
+	}
+
+	for idx, app := range c.Apps.Transmission {
+		if app.Enabled() && app.Client != nil {
+			reading, err := sampleTransmission(ctx, app)
+			add("transmission", idx, reading, err)
+		}
+	}
+
+	for idx, app := range c.Apps.SabNZB {
+		if app.Enabled() {
+			reading, err := sampleSabNZB(ctx, app)
+			add("sabnzbd", idx, reading, err)
+		}
+	}
+
+	for idx, app := range c.Apps.NZBGet {
+		if app.Enabled() {
+			reading, err := sampleNZBGet(ctx, app)
+			add("nzbget", idx, reading, err)
+		}
+	}
+
+	c.samples.Lock()
+	defer c.samples.Unlock()
+
+	if c.samples.values == nil {
+		c.samples.values = make(map[string][]*sample)
+	}
+
+	for key, reading := range readings {
+		values := append(c.samples.values[key], reading)
+		if len(values) > maxSamples {
+			values = values[len(values)-maxSamples:]
+		}
+
+		c.samples.values[key] = values
+	}
+}
+
+// addHistory attaches the sampled history to each downloader state.
+// The samples are reset when the states are sent to the website.
+func (c *Cmd) addHistory(states *States, reset bool) {
+	c.samples.Lock()
+	defer c.samples.Unlock()
+
+	for app, list := range map[string][]*State{
+		"deluge":       states.Deluge,
+		"qbit":         states.Qbit,
+		"rtorrent":     states.RTorrent,
+		"transmission": states.Xmission,
+		"sabnzbd":      states.SabNZB,
+		"nzbget":       states.NZBGet,
+	} {
+		for _, state := range list {
+			if values := c.samples.values[fmt.Sprint(app, state.Instance)]; len(values) > 0 {
+				state.History = &History{
+					Since:    c.samples.since,
+					Interval: cnfg.Duration{Duration: sampleInterval},
+					Samples:  len(values),
+					Download: series(values, func(s *sample) int64 { return s.download }),
+					Upload:   series(values, func(s *sample) int64 { return s.upload }),
+					Queue:    series(values, func(s *sample) int64 { return s.queue }),
+				}
+			}
+		}
+	}
+
+	if reset {
+		c.samples.values = make(map[string][]*sample)
+		c.samples.since = time.Now()
+	}
+}
+
+// series summarizes one value from the samples.
+func series(values []*sample, value func(*sample) int64) *Series {
+	output := &Series{Min: value(values[0]), Spark: []int64{}}
+
+	var total int64
+
+	for _, reading := range values {
+		val := value(reading)
+		total += val
+		output.Min = min(output.Min, val)
+		output.Max = max(output.Max, val)
+	}
+
+	output.Avg = total / int64(len(values))
+
+	// Average the samples into buckets so the sparkline has no more than sparkPoints.
+	for start := 0; start < len(values); {
+		end := start + (len(values)-start+sparkPoints-len(output.Spark)-1)/(sparkPoints-len(output.Spark))
+
+		var bucket int64
+		for _, reading := range values[start:end] {
+			bucket += value(reading)
+		}
+
+		output.Spark = append(output.Spark, bucket/int64(end-start))
+		start = end
+	}
+
+	return output
+}
+
+func sampleDeluge(ctx context.Context, app *apps.DelugeConfig) (*sample, error) {
+	xfers, err := app.GetXfersCompatContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting transfers: %w", err)
+	}
+
+	reading := &sample{queue: int64(len(xfers))}
+	for _, xfer := range xfers {
+		reading.download += int64(xfer.DownloadPayloadRate)
+		reading.upload += int64(xfer.UploadPayloadRate)
+	}
+
+	return reading, nil
+}
+
+func sampleQbit(ctx context.Context, app *apps.QbitConfig) (*sample, error) {
+	xfers, err := app.GetXfersContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting transfers: %w", err)
+	}
+
+	reading := &sample{queue: int64(len(xfers))}
+	for _, xfer := range xfers {
+		reading.download += int64(xfer.Dlspeed)
+		reading.upload += xfer.Upspeed
+	}
+
+	return reading, nil
+}
+
+func sampleRtorrent(app *apps.RtorrentConfig) (*sample, error) {
+	download, err := rTorrentInt(app, "throttle.global_down.rate")
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := rTorrentInt(app, "throttle.global_up.rate")
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := app.Call("download_list", "", "main")
+	if err != nil {
+		return nil, fmt.Errorf("%w: download_list XMLRPC call failed", err)
+	}
+
+	if lists, ok := list.([]interface{}); ok && len(lists) == 1 {
+		list = lists[0] // the xmlrpc library wraps the results in a list.
+	}
+
+	hashes, _ := list.([]interface{})
+
+	return &sample{download: download, upload: upload, queue: int64(len(hashes))}, nil
+}
+
+// rTorrentInt returns the integer result of an rTorrent XMLRPC method.
+func rTorrentInt(app *apps.RtorrentConfig, method string) (int64, error) {
+	result, err := app.Call(method)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s XMLRPC call failed", err, method)
+	}
+
+	if results, ok := result.([]interface{}); ok && len(results) > 0 {
+		result = results[0]
+	}
+
+	if value, ok := result.(int); ok {
+		return int64(value), nil
+	}
+
+	return 0, fmt.Errorf("%w: %s result isn't integer: %s", ErrInvalidResponse, method, result)
+}
+
+func sampleTransmission(ctx context.Context, app *apps.XmissionConfig) (*sample, error) {
+	stats, err := app.SessionStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting session stats: %w", err)
+	}
+
+	return &sample{download: stats.DownloadSpeed, upload: stats.UploadSpeed, queue: stats.TorrentCount}, nil
+}
+
+func sampleSabNZB(ctx context.Context, app *apps.SabNZBConfig) (*sample, error) {
+	queue, err := app.GetQueue(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting queue: %w", err)
+	}
+
+	return &sample{download: int64(queue.Kbpersec * mnd.Kilobyte), queue: int64(queue.NoofslotsTotal)}, nil
+}
+
+func sampleNZBGet(ctx context.Context, app *apps.NZBGetConfig) (*sample, error) {
+	status, err := app.StatusContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting status: %w", err)
+	}
+
+	groups, err := app.ListGroupsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting groups: %w", err)
+	}
+
+	return &sample{download: status.DownloadRate, queue: int64(len(groups))}, nil
+}
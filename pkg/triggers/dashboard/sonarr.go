@@ -77,6 +77,10 @@ func (c *Cmd) getSonarrState(ctx context.Context, instance int, app *apps.Sonarr
 		return state, fmt.Errorf("instance %d: %w", instance, err)
 	}
 
+	if err = c.getSonarrStorage(ctx, state, app); err != nil {
+		return state, fmt.Errorf("instance %d: %w", instance, err)
+	}
+
 	return state, nil
 }
 
@@ -0,0 +1,278 @@
+package dashboard
+
+/* This file collects root folder free space and import failures from the starr apps. */
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/readarr"
+)
+
+// These are the default low space thresholds, used when the website does not provide them.
+// A root folder is low on space when the free space is below a percent of the disk,
+// or below a number of bytes if the disk size is unknown.
+const (
+	defaultLowSpacePct   = 5
+	defaultLowSpaceBytes = 10 * mnd.Megabyte * mnd.Kilobyte
+)
+
+// These control which import failures are counted.
+const (
+	failureWindow   = 24 * time.Hour
+	failurePageSize = 100
+)
+
+// Sonarr and Radarr have no import failed history event. A failed import
+// stays in the queue with one of these tracked download states instead.
+const (
+	stateImportBlocked = "importBlocked" // v4
+	stateImportPending = "importPending" // v3, with a warning status.
+	statusWarning      = "warning"
+)
+
+// RootFolder is the space available in a starr app root folder.
+type RootFolder struct {
+	Path     string `json:"path"`
+	Free     int64  `json:"free"`
+	Total    int64  `json:"total,omitempty"`    // zero if the disk was not found.
+	Unmapped *int   `json:"unmapped,omitempty"` // folders not in the library. Sonarr and Radarr only.
+	Low      bool   `json:"low,omitempty"`
+}
+
+// disk is a starr disk space entry, used to find the size of the disk a root folder is on.
+type disk struct {
+	path  string
+	total int64
+}
+
+func (c *Cmd) getLidarrStorage(ctx context.Context, state *State, app *apps.LidarrConfig) error {
+	folders, err := app.GetRootFoldersContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting root folders: %w", err)
+	}
+
+	spaces, err := app.GetDiskSpaceContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting disk space: %w", err)
+	}
+
+	disks := []*disk{}
+	for _, space := range spaces {
+		disks = append(disks, &disk{path: space.Path, total: space.TotalSpace})
+	}
+
+	for _, folder := range folders {
+		state.RootFolders = append(state.RootFolders, &RootFolder{
+			Path: folder.Path, Free: folder.FreeSpace, Total: folder.TotalSpace,
+		})
+	}
+
+	history, err := app.GetHistoryPageContext(ctx, failurePage(lidarr.FilterImportFailed))
+	if err != nil {
+		return fmt.Errorf("getting import failed history: %w", err)
+	}
+
+	for _, rec := range history.Records {
+		countFailure(state, rec.Date)
+	}
+
+	checkSpace(state, disks)
+
+	return nil
+}
+
+func (c *Cmd) getRadarrStorage(ctx context.Context, state *State, app *apps.RadarrConfig) error {
+	folders, err := app.GetRootFoldersContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting root folders: %w", err)
+	}
+
+	spaces, err := app.GetDiskSpaceContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting disk space: %w", err)
+	}
+
+	disks := []*disk{}
+	for _, space := range spaces {
+		disks = append(disks, &disk{path: space.Path, total: space.TotalSpace})
+	}
+
+	for _, folder := range folders {
+		state.RootFolders = append(state.RootFolders, &RootFolder{
+			Path: folder.Path, Free: folder.FreeSpace, Unmapped: unmapped(len(folder.UnmappedFolders)),
+		})
+	}
+
+	queue, err := app.GetQueueContext(ctx, failurePageSize, failurePageSize)
+	if err != nil {
+		return fmt.Errorf("getting queue: %w", err)
+	}
+
+	for _, rec := range queue.Records {
+		countBlocked(state, rec.TrackedDownloadState, rec.TrackedDownloadStatus)
+	}
+
+	checkSpace(state, disks)
+
+	return nil
+}
+
+func (c *Cmd) getReadarrStorage(ctx context.Context, state *State, app *apps.ReadarrConfig) error {
+	folders, err := app.GetRootFoldersContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting root folders: %w", err)
+	}
+
+	spaces, err := app.GetDiskSpaceContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting disk space: %w", err)
+	}
+
+	disks := []*disk{}
+	for _, space := range spaces {
+		disks = append(disks, &disk{path: space.Path, total: space.TotalSpace})
+	}
+
+	for _, folder := range folders {
+		state.RootFolders = append(state.RootFolders, &RootFolder{
+			Path: folder.Path, Free: folder.FreeSpace, Total: folder.TotalSpace,
+		})
+	}
+
+	history, err := app.GetHistoryPageContext(ctx, failurePage(readarr.FilterImportFailed))
+	if err != nil {
+		return fmt.Errorf("getting import failed history: %w", err)
+	}
+
+	for _, rec := range history.Records {
+		countFailure(state, rec.Date)
+	}
+
+	checkSpace(state, disks)
+
+	return nil
+}
+
+func (c *Cmd) getSonarrStorage(ctx context.Context, state *State, app *apps.SonarrConfig) error {
+	folders, err := app.GetRootFoldersContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting root folders: %w", err)
+	}
+
+	spaces, err := app.GetDiskSpaceContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting disk space: %w", err)
+	}
+
+	disks := []*disk{}
+	for _, space := range spaces {
+		disks = append(disks, &disk{path: space.Path, total: space.TotalSpace})
+	}
+
+	for _, folder := range folders {
+		state.RootFolders = append(state.RootFolders, &RootFolder{
+			Path: folder.Path, Free: folder.FreeSpace, Unmapped: unmapped(len(folder.UnmappedFolders)),
+		})
+	}
+
+	queue, err := app.GetQueueContext(ctx, failurePageSize, failurePageSize)
+	if err != nil {
+		return fmt.Errorf("getting queue: %w", err)
+	}
+
+	for _, rec := range queue.Records {
+		countBlocked(state, rec.TrackedDownloadState, rec.TrackedDownloadStatus)
+	}
+
+	checkSpace(state, disks)
+
+	return nil
+}
+
+// failurePage returns the history request for the newest failures.
+func failurePage(filter starr.Filtering) *starr.PageReq {
+	return &starr.PageReq{
+		Page:     1,
+		PageSize: failurePageSize,
+		SortDir:  starr.SortDescend,
+		SortKey:  "date",
+		Filter:   filter,
+	}
+}
+
+// countFailure adds a history failure to the state if it happened within the failure window.
+func countFailure(state *State, date time.Time) {
+	if time.Since(date) < failureWindow {
+		state.ImportFailures++
+	}
+}
+
+// countBlocked adds a queue item to the state's import failures if its import failed.
+func countBlocked(state *State, trackedState, trackedStatus string) {
+	if trackedState == stateImportBlocked || (trackedState == stateImportPending && trackedStatus == statusWarning) {
+		state.ImportFailures++
+	}
+}
+
+// unmapped returns a pointer, so apps without unmapped folders leave the field out.
+func unmapped(count int) *int {
+	return &count
+}
+
+// lowSpace returns the low space thresholds from the website, or the defaults.
+func lowSpace() (int64, int64) {
+	pct, size := int64(defaultLowSpacePct), int64(defaultLowSpaceBytes)
+
+	if ci := clientinfo.Get(); ci != nil {
+		if ci.Actions.Dashboard.LowSpacePct > 0 {
+			pct = ci.Actions.Dashboard.LowSpacePct
+		}
+
+		if ci.Actions.Dashboard.LowSpaceBytes > 0 {
+			size = ci.Actions.Dashboard.LowSpaceBytes
+		}
+	}
+
+	return pct, size
+}
+
+// checkSpace fills in the root folder disk sizes and sets a warning on the state for folders low on space.
+// The disk for a root folder is the disk space entry with the longest path that contains the folder.
+func checkSpace(state *State, disks []*disk) {
+	low := []string{}
+	lowPct, lowBytes := lowSpace()
+
+	for _, folder := range state.RootFolders {
+		if folder.Total == 0 {
+			var found string
+
+			for _, disk := range disks {
+				if len(disk.path) > len(found) && strings.HasPrefix(folder.Path, disk.path) {
+					found, folder.Total = disk.path, disk.total
+				}
+			}
+		}
+
+		if folder.Total > 0 {
+			folder.Low = folder.Free*100 < folder.Total*lowPct
+		} else {
+			folder.Low = folder.Free < lowBytes
+		}
+
+		if folder.Low {
+			low = append(low, fmt.Sprintf("%s (%s free)", folder.Path, mnd.FormatBytes(folder.Free)))
+		}
+	}
+
+	if len(low) > 0 {
+		state.Warning = "low space on root folders: " + strings.Join(low, ", ")
+	}
+}
@@ -0,0 +1,106 @@
+package data
+
+/* This file contains the debug API handler for the data store. */
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ErrNoKey is returned by the API handler when a key is not in the store.
+var ErrNoKey = errors.New("key not found in data store")
+
+// Info describes an item in the data store.
+type Info struct {
+	Key        string    `json:"key"`
+	Created    time.Time `json:"created"`
+	LastAccess time.Time `json:"lastAccess"`
+	Age        string    `json:"age"`
+	Hits       int64     `json:"hits"`
+	Size       int       `json:"size"` // bytes, when encoded as json.
+	Persistent bool      `json:"persistent"`
+	Restored   bool      `json:"restored"` // loaded from disk, and not updated since.
+	Data       any       `json:"data,omitempty"`
+}
+
+// @Description  Returns a single item from the data store, including its data.
+// @Summary      Retrieve a data store item.
+// @Tags         Client
+// @Produce      json
+// @Param        key  path   string  true  "data store key"
+// @Success      200  {object} apps.Respond.apiResponse{message=Info} "the item and its data"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "key not found, or bad token or api key"
+// @Router       /api/data/{key} [get]
+// @Security     ApiKeyAuth
+func _() {}
+
+// Handler returns information about the items in the data store. This is for debugging.
+// @Description  Returns every key in the data store, with its age, size and whether it's saved to disk.
+// @Description  Data is not included; request a single key to see it.
+// @Summary      Retrieve data store keys.
+// @Tags         Client
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=[]Info} "list of items"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/data [get]
+// @Security     ApiKeyAuth
+func Handler(req *http.Request) (int, interface{}) {
+	key := mux.Vars(req)["key"]
+	if key == "" {
+		return http.StatusOK, list()
+	}
+
+	item := cached().List()[key] // List does not count as a hit.
+	if item == nil {
+		return http.StatusNotFound, ErrNoKey
+	}
+
+	info := newInfo(key, item.Data, restoredTime(key, item).Time, item.Last, item.Hits)
+	info.Data = item.Data
+
+	return http.StatusOK, info
+}
+
+func list() []*Info {
+	output := []*Info{}
+
+	for key, item := range cached().List() {
+		output = append(output, newInfo(key, item.Data, restoredTime(key, item).Time, item.Last, item.Hits))
+	}
+
+	sort.Slice(output, func(i, j int) bool { return output[i].Key < output[j].Key })
+
+	return output
+}
+
+func newInfo(key string, data any, created, last time.Time, hits int64) *Info {
+	store.RLock()
+	_, restored := store.restored[key]
+	store.RUnlock()
+
+	persist.Lock()
+	config := persist.config
+	persistent := persistentKey(key) != "" && config != nil && config.Persist && config.selected(key)
+	persist.Unlock()
+
+	size := 0
+	if content, err := json.Marshal(data); err == nil {
+		size = len(content)
+	}
+
+	return &Info{
+		Key:        key,
+		Created:    created,
+		LastAccess: last,
+		Age:        time.Since(created).Round(time.Second).String(),
+		Hits:       hits,
+		Size:       size,
+		Persistent: persistent,
+		Restored:   restored,
+	}
+}
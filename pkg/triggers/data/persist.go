@@ -0,0 +1,245 @@
+package data
+
+/* This file contains the procedures to save selected keys to disk, and load them on startup. */
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"golift.io/cache"
+)
+
+// decoder turns a saved item back into the type that was stored.
+type decoder func(raw json.RawMessage) (any, error)
+
+// persist holds the keys that may be saved to disk, and the settings for saving them.
+//
+//nolint:gochecknoglobals
+var persist = struct {
+	decoders map[string]decoder
+	keep     map[string]bool // keys loaded no matter how old they are.
+	config   *Config
+	path     string
+	setup    bool
+	sync.Mutex
+}{
+	decoders: make(map[string]decoder),
+	keep:     make(map[string]bool),
+}
+
+// savedItem is the format of each item in the data file.
+type savedItem struct {
+	Time  time.Time `json:"time"`
+	Prune bool      `json:"prune,omitempty"`
+	Data  any       `json:"data"`
+}
+
+// loadedItem is savedItem with its data left as json until the key's type is known.
+type loadedItem struct {
+	Time  time.Time       `json:"time"`
+	Prune bool            `json:"prune"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Persistent marks a key as safe to save to disk. T must be the type of data saved with the key.
+// Keys saved with SaveWithID are included; register them without their ID.
+// Call this before Setup, so the saved data can be loaded.
+func Persistent[T any](key string) {
+	persist.Lock()
+	defer persist.Unlock()
+
+	persist.decoders[key] = func(raw json.RawMessage) (any, error) {
+		var data T
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", key, err)
+		}
+
+		return data, nil
+	}
+}
+
+// PersistentKeep is like Persistent, but saved items with this key are loaded no matter how old they are.
+// Use it for data that is compared to the next run of a long timer, so max_age does not throw it away.
+func PersistentKeep[T any](key string) {
+	Persistent[T](key)
+
+	persist.Lock()
+	defer persist.Unlock()
+
+	persist.keep[key] = true
+}
+
+// Setup applies the prune settings, and loads saved data from path if persistence is enabled.
+// Prune settings and saved data are only applied the first time this runs, because the store
+// survives reloads. Items that already exist in memory are not replaced with saved data.
+func Setup(config *Config, path string) (int, error) {
+	if config == nil {
+		config = &Config{}
+	}
+
+	persist.Lock()
+	defer persist.Unlock()
+
+	persist.config = config
+	persist.path = path
+
+	if persist.setup {
+		return 0, nil
+	}
+
+	persist.setup = true
+	setupCache(config)
+
+	if !config.Persist || path == "" {
+		return 0, nil
+	}
+
+	return load(path, config)
+}
+
+// setupCache replaces the cache if the prune settings changed. Existing items are copied with their prune setting.
+func setupCache(config *Config) {
+	conf := cacheConfig(config)
+
+	store.Lock()
+	defer store.Unlock()
+
+	if conf == store.config {
+		return
+	}
+
+	replacement := cache.New(conf)
+	for key, item := range store.List() {
+		replacement.Save(key, item.Data, cache.Options{Prune: store.prune[key]})
+	}
+
+	store.Stop(true)
+	store.Cache = replacement
+	store.config = conf
+}
+
+// load must be called while holding the persist lock.
+func load(path string, config *Config) (int, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("reading data file: %w", err)
+	}
+
+	items := make(map[string]*loadedItem)
+	if err := json.Unmarshal(content, &items); err != nil {
+		return 0, fmt.Errorf("decoding data file %s: %w", path, err)
+	}
+
+	maxAge := config.MaxAge.Duration
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+
+	count := 0
+
+	for key, item := range items {
+		name := persistentKey(key)
+		decode := persist.decoders[name]
+
+		if item == nil || decode == nil || !config.selected(key) || (!persist.keep[name] && time.Since(item.Time) > maxAge) {
+			continue
+		}
+
+		if cached().Get(key) != nil {
+			continue // newer data is already in memory.
+		}
+
+		data, err := decode(item.Data)
+		if err != nil {
+			return count, err
+		}
+
+		// Items keep the prune setting they were saved with. Keys with an ID are not always pruned.
+		cached().Save(key, data, cache.Options{Prune: item.Prune})
+		store.Lock()
+		store.restored[key] = item.Time
+		store.prune[key] = item.Prune
+		store.Unlock()
+		count++
+	}
+
+	return count, nil
+}
+
+// Snapshot writes the selected keys to disk, if persistence is enabled. Returns how many items were saved.
+func Snapshot() (int, error) {
+	persist.Lock()
+	defer persist.Unlock()
+
+	if persist.config == nil || !persist.config.Persist || persist.path == "" {
+		return 0, nil
+	}
+
+	items := make(map[string]*savedItem)
+
+	store.RLock()
+	prune := maps.Clone(store.prune)
+	store.RUnlock()
+
+	for key, item := range cached().List() {
+		if persistentKey(key) != "" && persist.config.selected(key) && item.Data != nil {
+			items[key] = &savedItem{Time: restoredTime(key, item).Time, Prune: prune[key], Data: item.Data}
+		}
+	}
+
+	content, err := json.Marshal(items)
+	if err != nil {
+		return 0, fmt.Errorf("encoding data: %w", err)
+	}
+
+	if err := os.WriteFile(persist.path+".tmp", content, mnd.Mode0600); err != nil {
+		return 0, fmt.Errorf("writing data file: %w", err)
+	}
+
+	if err := os.Rename(persist.path+".tmp", persist.path); err != nil {
+		return 0, fmt.Errorf("renaming data file: %w", err)
+	}
+
+	return len(items), nil
+}
+
+// persistentKey returns the registered key for a cache key, or an empty string if it's not registered.
+// Cache keys saved with SaveWithID match their registered key when the rest is a number.
+func persistentKey(key string) string {
+	if _, ok := persist.decoders[key]; ok {
+		return key
+	}
+
+	for name := range persist.decoders {
+		if id, ok := strings.CutPrefix(key, name); ok && id != "" && strings.Trim(id, "0123456789") == "" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// selected returns true if the key is in the configured list of keys, or if the list is empty.
+func (c *Config) selected(key string) bool {
+	if len(c.Keys) == 0 {
+		return true
+	}
+
+	name := persistentKey(key)
+
+	for _, selected := range c.Keys {
+		if strings.EqualFold(selected, name) || strings.EqualFold(selected, key) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,38 +2,130 @@ package data
 
 import (
 	"strconv"
+	"sync"
 	"time"
 
 	"golift.io/cache"
+	"golift.io/cnfg"
 )
 
+// Default prune settings.
+const (
+	DefaultPruneInterval = 5 * time.Minute
+	DefaultPruneAfter    = time.Hour
+	DefaultMaxAge        = 24 * time.Hour
+)
+
+// Config controls the data store's pruning, and which keys are saved to disk.
+type Config struct {
+	// Persist saves selected keys to disk when the app stops, and loads them when it starts.
+	Persist bool `json:"persist"       toml:"persist"        xml:"persist"        yaml:"persist"`
+	// Keys limits persistence to these keys. Empty saves every key that supports it.
+	Keys []string `json:"keys"          toml:"keys"           xml:"keys"           yaml:"keys"`
+	// MaxAge discards saved items older than this when they're loaded.
+	MaxAge cnfg.Duration `json:"maxAge"        toml:"max_age"        xml:"max_age"        yaml:"maxAge"`
+	// PruneInterval is how often the store looks for items to prune.
+	PruneInterval cnfg.Duration `json:"pruneInterval" toml:"prune_interval" xml:"prune_interval" yaml:"pruneInterval"`
+	// PruneAfter prunes items saved with an ID (like queues) that have not been used for this long.
+	PruneAfter cnfg.Duration `json:"pruneAfter"    toml:"prune_after"    xml:"prune_after"    yaml:"pruneAfter"`
+}
+
 // store provides a shared concurrency-safe data cache for our triggers (and web server).
 // This cache is also immune from being purged during reload.
 //
-//nolint:gochecknoglobals,mnd
-var store = cache.New(cache.Config{
-	RequestAccuracy: 15 * time.Second,
-	PruneInterval:   5 * time.Minute,
-	PruneAfter:      time.Hour,
-	MaxUnused:       1 << 62,
-})
-
-// Save a piece of data in the cache.
+//nolint:gochecknoglobals
+var store = struct {
+	*cache.Cache
+	config   cache.Config
+	restored map[string]time.Time // original save time for items loaded from disk.
+	prune    map[string]bool      // keys saved with prune enabled, so a replacement cache keeps it.
+	sync.RWMutex
+}{
+	Cache:    cache.New(cacheConfig(nil)),
+	config:   cacheConfig(nil),
+	restored: make(map[string]time.Time),
+	prune:    make(map[string]bool),
+}
+
+func cacheConfig(config *Config) cache.Config {
+	conf := cache.Config{
+		RequestAccuracy: 15 * time.Second, //nolint:mnd
+		PruneInterval:   DefaultPruneInterval,
+		PruneAfter:      DefaultPruneAfter,
+		MaxUnused:       1 << 62, //nolint:mnd
+	}
+
+	if config != nil && config.PruneInterval.Duration > 0 {
+		conf.PruneInterval = config.PruneInterval.Duration
+	}
+
+	if config != nil && config.PruneAfter.Duration > 0 {
+		conf.PruneAfter = config.PruneAfter.Duration
+	}
+
+	return conf
+}
+
+// cached returns the running cache. Setup may replace it.
+func cached() *cache.Cache {
+	store.RLock()
+	defer store.RUnlock()
+
+	return store.Cache
+}
+
+// Save a piece of data in the cache. Data must not be changed after it's saved, because the
+// API handler and Snapshot encode it from other goroutines. To change it, save a new copy.
 func Save(key string, data interface{}) {
-	store.Save(key, data, cache.Options{})
+	saved(key, false)
+	cached().Save(key, data, cache.Options{})
 }
 
 // Get an itemfrom the cache. May be nil if non-existent.
 func Get(key string) *cache.Item {
-	return store.Get(key)
+	return restoredTime(key, cached().Get(key))
 }
 
 // SaveWithID saves data to the cache, and appends the key to an id.
+// These items are pruned when unused. Like Save, data must not be changed after it's saved.
 func SaveWithID(key string, id int, data interface{}) {
-	store.Save(key+strconv.Itoa(id), data, cache.Options{Prune: true})
+	saved(key+strconv.Itoa(id), true)
+	cached().Save(key+strconv.Itoa(id), data, cache.Options{Prune: true})
 }
 
 // GetWithID returns data from the cache using a kay appended to an id.
 func GetWithID(key string, id int) *cache.Item {
-	return store.Get(key + strconv.Itoa(id))
+	return restoredTime(key+strconv.Itoa(id), cached().Get(key+strconv.Itoa(id)))
+}
+
+// saved forgets the original time of a restored item, because it was replaced.
+// It also records whether the key is pruned.
+func saved(key string, prune bool) {
+	store.Lock()
+	defer store.Unlock()
+
+	delete(store.restored, key)
+
+	if prune {
+		store.prune[key] = true
+	} else {
+		delete(store.prune, key)
+	}
+}
+
+// restoredTime sets the time on an item loaded from disk to when it was originally saved,
+// so code checking an item's age is not fooled by a restart.
+func restoredTime(key string, item *cache.Item) *cache.Item {
+	if item == nil {
+		return nil
+	}
+
+	store.RLock()
+	defer store.RUnlock()
+
+	if saved, ok := store.restored[key]; ok {
+		item.Time = saved
+	}
+
+	return item
 }
@@ -0,0 +1,196 @@
+package filewatch
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nxadm/tail"
+)
+
+// defaultSamples is how many example lines are kept for each deduped match in a window.
+const defaultSamples = 3
+
+// maxGroups caps the deduped matches kept in a window. Matches with new keys past the cap are counted in otherKey,
+// so a file full of unique lines cannot grow the window without limit.
+const (
+	maxGroups = 100
+	otherKey  = "other"
+)
+
+// digits is used to normalize lines, so lines that differ only by numbers (timestamps, IDs) dedupe together.
+var digits = regexp.MustCompile(`[0-9]+`)
+
+// MatchGroup is a set of deduped matches collected during an aggregation window.
+type MatchGroup struct {
+	Key     string    `json:"key"`
+	Count   uint      `json:"count"`
+	First   time.Time `json:"first"`
+	Last    time.Time `json:"last"`
+	Samples []string  `json:"samples"`
+}
+
+// aggregate collects matches for a single watched file while its window is open.
+// The drop counter is used by all watchers, even those without a window.
+type aggregate struct {
+	file   *WatchFile
+	drops  atomic.Uint64
+	mu     sync.Mutex
+	timer  *time.Timer
+	groups []*MatchGroup
+	keys   map[string]*MatchGroup
+	count  uint
+	line   string
+	// stopped is set when the watcher stops, so a late timer or line does not send after a reload.
+	stopped bool
+}
+
+// sender is called to send a summary when a window closes. It returns false if the summary was dropped.
+type sender func(*WatchFile, *Match) bool
+
+func newAggregate(file *WatchFile) *aggregate {
+	return &aggregate{file: file, keys: make(map[string]*MatchGroup)}
+}
+
+// add a matched line to the window, and open the window if it's closed.
+func (a *aggregate) add(line *tail.Line, send sender) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopped {
+		return
+	}
+
+	text := strings.TrimSpace(line.Text)
+	key := a.key(text)
+
+	if a.keys[key] == nil && len(a.keys) >= maxGroups {
+		key = otherKey
+	}
+
+	group := a.keys[key]
+	if group == nil {
+		group = &MatchGroup{Key: key, First: line.Time}
+		a.keys[key] = group
+		a.groups = append(a.groups, group)
+	}
+
+	group.Count++
+	group.Last = line.Time
+	a.count++
+	a.line = text
+
+	samples := a.file.Samples
+	if samples == 0 {
+		samples = defaultSamples
+	}
+
+	if uint(len(group.Samples)) < samples {
+		group.Samples = append(group.Samples, text)
+	}
+
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.file.Window.Duration, func() { a.flush(send) })
+	}
+}
+
+// key returns the dedupe key for a line: a capture group, or the normalized line.
+func (a *aggregate) key(text string) string {
+	if group := int(a.file.DedupeGroup); group > 0 && a.file.re != nil {
+		if matches := a.file.re.FindStringSubmatch(text); len(matches) > group && matches[group] != "" {
+			return matches[group]
+		}
+	}
+
+	return digits.ReplaceAllString(strings.Join(strings.Fields(text), " "), "#")
+}
+
+// flush sends a summary of the collected matches and closes the window.
+// If the summary gets rate limited, the window is re-opened and the matches are kept.
+func (a *aggregate) flush(send sender) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.stopped { // the timer may fire while the watcher stops.
+		a.send(send, true)
+	}
+}
+
+// stop closes the window for good. The collected matches are sent once, and not retried if rate limited.
+func (a *aggregate) stop(send sender) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stopped = true
+	a.send(send, false)
+}
+
+// send must be called while holding the lock. It stops the timer, and re-opens the window if
+// the summary is rate limited and retry is true.
+func (a *aggregate) send(send sender, retry bool) {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+
+	if a.count == 0 {
+		return
+	}
+
+	first, last := a.groups[0].First, a.groups[0].Last
+	for _, group := range a.groups {
+		if group.First.Before(first) {
+			first = group.First
+		}
+
+		if group.Last.After(last) {
+			last = group.Last
+		}
+	}
+
+	match := &Match{
+		File:    a.file.Path,
+		Line:    a.line,
+		Count:   a.count,
+		First:   &first,
+		Last:    &last,
+		Groups:  a.groups,
+		Matches: a.file.re.FindAllString(a.line, -1),
+	}
+
+	if !send(a.file, match) && retry {
+		a.timer = time.AfterFunc(a.file.Window.Duration, func() { a.flush(send) })
+		return
+	}
+
+	a.groups = nil
+	a.keys = make(map[string]*MatchGroup)
+	a.count = 0
+	a.line = ""
+}
+
+// drop increments the counter for matches dropped by the rate limiter.
+func (a *aggregate) drop() {
+	if a != nil {
+		a.drops.Add(1)
+	}
+}
+
+// dropped returns and resets the counter for matches dropped by the rate limiter.
+func (a *aggregate) dropped() uint {
+	if a == nil {
+		return 0
+	}
+
+	return uint(a.drops.Swap(0))
+}
@@ -0,0 +1,187 @@
+package filewatch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nxadm/tail"
+	"github.com/stretchr/testify/assert"
+	"golift.io/cnfg"
+)
+
+// testFile returns a watched file with a window, for aggregation tests.
+func testFile(window time.Duration, regex string, group uint) *WatchFile {
+	return &WatchFile{
+		Path:        "/var/log/test.log",
+		Regexp:      regex,
+		Window:      cnfg.Duration{Duration: window},
+		DedupeGroup: group,
+		re:          regexp.MustCompile(regex),
+	}
+}
+
+// testSender records the summaries it's given, and returns the next result in results, then true.
+type testSender struct {
+	mu      sync.Mutex
+	results []bool
+	matches []*Match
+	sent    chan *Match
+}
+
+func newTestSender(results ...bool) *testSender {
+	return &testSender{results: results, sent: make(chan *Match, 10)}
+}
+
+func (s *testSender) send(_ *WatchFile, match *Match) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := true
+	if len(s.results) > 0 {
+		result, s.results = s.results[0], s.results[1:]
+	}
+
+	s.matches = append(s.matches, match)
+	s.sent <- match
+
+	return result
+}
+
+func (s *testSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.matches)
+}
+
+func TestAggregateGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		regex string
+		group uint
+		lines []string
+		keys  []string
+		count []uint
+	}{
+		{
+			name:  "numbers are normalized",
+			regex: "error",
+			lines: []string{"error 123 in job 4", "error  456 in job 7 ", "another error"},
+			keys:  []string{"error # in job #", "another error"},
+			count: []uint{2, 1},
+		},
+		{
+			name:  "capture group",
+			regex: `error: (\w+)`,
+			group: 1,
+			lines: []string{"error: disk full at 1", "error: timeout", "error: disk again"},
+			keys:  []string{"disk", "timeout"},
+			count: []uint{2, 1},
+		},
+		{
+			name:  "missing capture group falls back to the line",
+			regex: `error(: (\w+))?`,
+			group: 2,
+			lines: []string{"error 1", "error 2", "error: disk"},
+			keys:  []string{"error #", "disk"},
+			count: []uint{2, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			agg := newAggregate(testFile(time.Hour, test.regex, test.group))
+			sender := newTestSender()
+
+			for _, line := range test.lines {
+				agg.add(&tail.Line{Text: line, Time: time.Now()}, sender.send)
+			}
+
+			agg.stop(sender.send)
+
+			if assert.Equal(t, 1, sender.count(), "stop must send the open window") {
+				match := sender.matches[0]
+				assert.Equal(t, uint(len(test.lines)), match.Count)
+				assert.Len(t, match.Groups, len(test.keys))
+
+				for idx, group := range match.Groups {
+					assert.Equal(t, test.keys[idx], group.Key)
+					assert.Equal(t, test.count[idx], group.Count)
+				}
+			}
+		})
+	}
+}
+
+func TestAggregateCap(t *testing.T) {
+	t.Parallel()
+
+	agg := newAggregate(testFile(time.Hour, "line", 0))
+	sender := newTestSender()
+
+	for idx := range maxGroups + 5 {
+		agg.add(&tail.Line{Text: "line " + strings.Repeat("x", idx+1), Time: time.Now()}, sender.send)
+	}
+
+	// Keys that exist keep counting after the cap.
+	agg.add(&tail.Line{Text: "line x", Time: time.Now()}, sender.send)
+	assert.Len(t, agg.keys, maxGroups+1, "keys past the cap must share one group")
+	assert.Equal(t, uint(5), agg.keys[otherKey].Count)
+	assert.Equal(t, uint(2), agg.keys["line x"].Count)
+	agg.stop(sender.send)
+}
+
+func TestAggregateFlush(t *testing.T) {
+	t.Parallel()
+
+	agg := newAggregate(testFile(10*time.Millisecond, "error", 0))
+	sender := newTestSender(false) // the first summary is rate limited.
+
+	for idx := range 3 {
+		agg.add(&tail.Line{Text: fmt.Sprint("error ", idx), Time: time.Now()}, sender.send)
+	}
+
+	for attempt := range 2 {
+		select {
+		case match := <-sender.sent:
+			assert.Equal(t, uint(3), match.Count, "the matches must be kept when the summary is rate limited")
+			assert.Len(t, match.Groups, 1)
+			assert.Equal(t, []string{"error 0", "error 1", "error 2"}, match.Groups[0].Samples)
+		case <-time.After(time.Second):
+			t.Fatalf("window did not flush, attempt %d", attempt+1)
+		}
+	}
+
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	assert.Nil(t, agg.timer, "the window must close after the summary is sent")
+	assert.Zero(t, agg.count)
+	assert.Empty(t, agg.keys)
+}
+
+func TestAggregateStop(t *testing.T) {
+	t.Parallel()
+
+	agg := newAggregate(testFile(20*time.Millisecond, "error", 0))
+	sender := newTestSender(false) // stopping does not retry a rate limited summary.
+
+	agg.add(&tail.Line{Text: "error", Time: time.Now()}, sender.send)
+	agg.stop(sender.send)
+	assert.Equal(t, 1, sender.count(), "stop must send the open window once")
+	assert.Nil(t, agg.timer, "stop must stop the window timer")
+
+	agg.add(&tail.Line{Text: "error", Time: time.Now()}, sender.send)
+	agg.flush(sender.send)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, sender.count(), "nothing may be sent after stop")
+	assert.Nil(t, agg.timer, "lines after stop must not open a window")
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/nxadm/tail"
 	"github.com/nxadm/tail/ratelimiter"
+	"golift.io/cnfg"
 )
 
 var (
@@ -51,25 +52,37 @@ type Action struct {
 
 // WatchFile is the input data needed to watch files.
 type WatchFile struct {
-	Path      string `json:"path"      toml:"path"       xml:"path"       yaml:"path"`
-	Regexp    string `json:"regex"     toml:"regex"      xml:"regex"      yaml:"regex"`
-	Skip      string `json:"skip"      toml:"skip"       xml:"skip"       yaml:"skip"`
-	Poll      bool   `json:"poll"      toml:"poll"       xml:"poll"       yaml:"poll"`
-	Pipe      bool   `json:"pipe"      toml:"pipe"       xml:"pipe"       yaml:"pipe"`
-	MustExist bool   `json:"mustExist" toml:"must_exist" xml:"must_exist" yaml:"mustExist"`
-	LogMatch  bool   `json:"logMatch"  toml:"log_match"  xml:"log_match"  yaml:"logMatch"`
-	re        *regexp.Regexp
-	skip      *regexp.Regexp
-	tail      *tail.Tail
-	mu        sync.RWMutex
-	retries   uint
+	Path        string        `json:"path"        toml:"path"         xml:"path"         yaml:"path"`
+	Regexp      string        `json:"regex"       toml:"regex"        xml:"regex"        yaml:"regex"`
+	Skip        string        `json:"skip"        toml:"skip"         xml:"skip"         yaml:"skip"`
+	Poll        bool          `json:"poll"        toml:"poll"         xml:"poll"         yaml:"poll"`
+	Pipe        bool          `json:"pipe"        toml:"pipe"         xml:"pipe"         yaml:"pipe"`
+	MustExist   bool          `json:"mustExist"   toml:"must_exist"   xml:"must_exist"   yaml:"mustExist"`
+	LogMatch    bool          `json:"logMatch"    toml:"log_match"    xml:"log_match"    yaml:"logMatch"`
+	Window      cnfg.Duration `json:"window"      toml:"window"       xml:"window"       yaml:"window"`
+	DedupeGroup uint          `json:"dedupeGroup" toml:"dedupe_group" xml:"dedupe_group" yaml:"dedupeGroup"`
+	Samples     uint          `json:"samples"     toml:"samples"      xml:"samples"      yaml:"samples"`
+	re          *regexp.Regexp
+	skip        *regexp.Regexp
+	tail        *tail.Tail
+	mu          sync.RWMutex
+	retries     uint
+	agg         *aggregate
 }
 
 // Match is what we send to the website.
+// The aggregation fields are only populated when the watcher has a window configured.
 type Match struct {
 	File    string   `json:"file"`
 	Matches []string `json:"matches"`
 	Line    string   `json:"line"`
+	// Dropped is how many matches the rate limiter dropped since the previous match was sent.
+	Dropped uint `json:"dropped,omitempty"`
+	// Count is the total number of matches collected in the window.
+	Count  uint          `json:"count,omitempty"`
+	First  *time.Time    `json:"first,omitempty"`
+	Last   *time.Time    `json:"last,omitempty"`
+	Groups []*MatchGroup `json:"groups,omitempty"`
 }
 
 // New configures the library.
@@ -143,6 +156,8 @@ func (c *cmd) run() {
 	validTails := []*WatchFile{{Path: "/add watcher channel/"}, {Path: "/retry ticker/"}}
 
 	for _, item := range c.files {
+		item.agg = nil // a reload starts new windows; the previous ones were stopped.
+
 		if err := item.setup(&logger{Logger: c.Config.Logger}, c.ignored); err != nil {
 			c.Errorf("Unable to watch file: %v", err)
 			continue
@@ -191,6 +206,10 @@ func (w *WatchFile) setup(logger *logger, ignored ignored) error {
 
 	w.retries = 0
 
+	if w.agg == nil {
+		w.agg = newAggregate(w)
+	}
+
 	return nil
 }
 
@@ -335,24 +354,48 @@ func (c *cmd) checkLineMatch(line *tail.Line, tail *WatchFile) {
 
 	mnd.FileWatcher.Add(tail.Path+Matched, 1)
 
-	match := &Match{
+	if tail.Window.Duration > 0 {
+		tail.agg.add(line, c.sendMatch)
+		return // the summary is sent when the window closes.
+	}
+
+	c.sendMatch(tail, &Match{
 		File:    tail.Path,
 		Line:    strings.TrimSpace(line.Text),
 		Matches: tail.re.FindAllString(line.Text, -1),
-	}
+	})
+}
 
+// sendMatch pours into the rate limiter and sends the match to the website if there is room.
+// Returns false if the match was dropped, so aggregated matches may be retried.
+func (c *cmd) sendMatch(tail *WatchFile, match *Match) bool {
 	if !c.limiter.Pour(1) {
+		if match.Count > 0 {
+			return false // rate limited, aggregated matches are retried.
+		}
+
 		mnd.FileWatcher.Add(tail.Path+" Dropped", 1)
-		return // rate limited.
+		tail.agg.drop()
+
+		return false // rate limited.
+	}
+
+	match.Dropped = tail.agg.dropped()
+	msg := fmt.Sprintf("Watched-File Line Match: %s: %s", tail.Path, match.Line)
+
+	if match.Count > 0 {
+		msg = fmt.Sprintf("Watched-File Line Matches: %s: %d matches, %d unique", tail.Path, match.Count, len(match.Groups))
 	}
 
 	c.SendData(&website.Request{
 		Route:      website.LogLineRoute,
 		Event:      website.EventFile,
 		LogPayload: tail.LogMatch,
-		LogMsg:     fmt.Sprintf("Watched-File Line Match: %s: %s", tail.Path, match.Line),
+		LogMsg:     msg,
 		Payload:    match,
 	})
+
+	return true
 }
 
 func (a *Action) AddFileWatcher(file *WatchFile) error {
@@ -398,6 +441,8 @@ func (c *cmd) stop() {
 		if err := tail.Stop(); err != nil {
 			c.Errorf("Stopping File Watcher: %s: %v", tail.Path, err)
 		}
+
+		tail.agg.stop(c.sendMatch) // send what we have before the reload.
 	}
 
 	// The following code might wait for all the watchers to die before returning.
@@ -1,6 +1,7 @@
 package triggers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"path"
@@ -9,7 +10,9 @@ import (
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/logs/share"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/commands"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/workflow"
 	"github.com/Notifiarr/notifiarr/pkg/ui"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/gorilla/mux"
@@ -26,13 +29,17 @@ func (a *Actions) APIHandler(req *http.Request) (int, interface{}) {
 // Handler handles GUI (non-API) trigger requests.
 func (a *Actions) Handler(response http.ResponseWriter, req *http.Request) {
 	code, data := a.handleTrigger(req, website.EventGUI)
-	http.Error(response, data, code)
+	http.Error(response, fmt.Sprint(data), code)
 }
 
 type trigger struct {
 	Name string `json:"name"`
 	Dur  string `json:"interval,omitempty"`
 	Path string `json:"apiPath,omitempty"`
+	// Cron schedule for scheduled triggers.
+	Schedule string `json:"schedule,omitempty"`
+	// The next time a scheduled trigger runs.
+	Next *time.Time `json:"next,omitempty"`
 }
 
 type timer struct {
@@ -42,6 +49,10 @@ type timer struct {
 	Idx int `json:"id"`
 	// The client API path to trigger this custom timer.
 	Path string `json:"apiPath"`
+	// Cron schedule, if the timer does not use an interval.
+	Schedule string `json:"schedule,omitempty"`
+	// The next time a scheduled timer runs.
+	Next *time.Time `json:"next,omitempty"`
 }
 
 type triggerOutput struct {
@@ -49,7 +60,7 @@ type triggerOutput struct {
 	Timers   []*timer   `json:"timers"`
 }
 
-// @Description  Returns a list of triggers and website timers with their intervals, if configured.
+// @Description  Returns a list of triggers and website timers with their intervals or schedules, if configured.
 // @Summary      Get trigger list
 // @Tags         Triggers
 // @Produce      json
@@ -75,6 +86,15 @@ func (a *Actions) HandleGetTriggers(_ *http.Request) (int, interface{}) {
 		}
 	}
 
+	for name, info := range a.Scheduled() {
+		if _, ok := temp[name]; !ok {
+			temp[name] = &trigger{Name: name}
+		}
+
+		temp[name].Schedule = info.Schedule
+		temp[name].Next = &info.Next
+	}
+
 	cronTimers := a.CronTimer.List()
 	reply := &triggerOutput{
 		Triggers: make([]*trigger, len(temp)),
@@ -90,10 +110,16 @@ func (a *Actions) HandleGetTriggers(_ *http.Request) (int, interface{}) {
 
 	for idx, action := range cronTimers {
 		reply.Timers[idx] = &timer{
-			Name: action.Name,
-			Dur:  action.Interval.String(),
-			Idx:  idx,
-			Path: path.Join(a.Apps.URLBase, fmt.Sprint("api/trigger/custom/", idx)),
+			Name:     action.Name,
+			Dur:      action.Interval.String(),
+			Idx:      idx,
+			Path:     path.Join(a.Apps.URLBase, fmt.Sprint("api/trigger/custom/", idx)),
+			Schedule: action.Schedule(),
+		}
+
+		if next := action.Next(); !next.IsZero() {
+			reply.Timers[idx].Dur = ""
+			reply.Timers[idx].Next = &next
 		}
 	}
 
@@ -101,7 +127,7 @@ func (a *Actions) HandleGetTriggers(_ *http.Request) (int, interface{}) {
 }
 
 // handleTrigger is an abstraction to deal with API or GUI triggers (they have different handlers).
-func (a *Actions) handleTrigger(req *http.Request, event website.EventType) (int, string) {
+func (a *Actions) handleTrigger(req *http.Request, event website.EventType) (int, interface{}) {
 	input := &common.ActionInput{Type: event}
 	trigger := mux.Vars(req)["trigger"]
 	content := mux.Vars(req)["content"]
@@ -118,7 +144,8 @@ func (a *Actions) handleTrigger(req *http.Request, event website.EventType) (int
 	return a.runTrigger(input, trigger, content)
 }
 
-func (a *Actions) runTrigger(input *common.ActionInput, trigger, content string) (int, string) { //nolint:cyclop
+//nolint:cyclop
+func (a *Actions) runTrigger(input *common.ActionInput, trigger, content string) (int, interface{}) {
 	switch trigger {
 	case "custom":
 		return a.customTimer(input, content)
@@ -136,6 +163,8 @@ func (a *Actions) runTrigger(input *common.ActionInput, trigger, content string)
 		return a.sessions(input)
 	case "stuckitems":
 		return a.stuckitems(input)
+	case "duplicates":
+		return a.duplicates(input)
 	case "dashboard":
 		return a.dashboard(input)
 	case "snapshot":
@@ -156,6 +185,8 @@ func (a *Actions) runTrigger(input *common.ActionInput, trigger, content string)
 		return a.mdblist(input)
 	case "uploadlog":
 		return a.uploadlog(input, content)
+	case "workflow":
+		return a.workflow(input, content)
 	default:
 		return http.StatusBadRequest, "Unknown trigger provided:'" + trigger + "'"
 	}
@@ -215,6 +246,7 @@ func (a *Actions) clientLogs(content string) (int, string) {
 // @Success      200  {object} apps.Respond.apiResponse{message=string} "success"
 // @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad or missing hash"
 // @Failure      404  {object} string "bad token or api key"
+// @Failure      422  {object} apps.Respond.apiResponse{message=commands.ArgValidation} "invalid args"
 // @Router       /api/trigger/command/{hash} [post]
 // @Security     ApiKeyAuth
 //
@@ -231,12 +263,21 @@ func _() {}
 // @Failure      404  {object} string "bad token or api key"
 // @Router       /api/trigger/command/{hash} [get]
 // @Security     ApiKeyAuth
-func (a *Actions) command(input *common.ActionInput, content string) (int, string) {
+func (a *Actions) command(input *common.ActionInput, content string) (int, interface{}) {
 	cmd := a.Commands.GetByHash(content)
 	if cmd == nil {
 		return http.StatusBadRequest, "No command hash provided."
 	}
 
+	if err := cmd.ValidateArgs(input.Args); err != nil {
+		var argErrs commands.ArgErrors
+		if errors.As(err, &argErrs) {
+			return http.StatusUnprocessableEntity, &commands.ArgValidation{Error: err.Error(), Args: argErrs}
+		}
+
+		return http.StatusBadRequest, err.Error()
+	}
+
 	cmd.Run(input)
 
 	return http.StatusOK, "Command triggered: " + cmd.Name
@@ -333,6 +374,20 @@ func (a *Actions) stuckitems(input *common.ActionInput) (int, string) {
 	return http.StatusOK, "Stuck Queue Items triggered."
 }
 
+// @Description  Checks the cached starr queues for downloads queued in more than one instance, and sends them.
+// @Description  Lower priority copies are removed from instances with remove_duplicates enabled.
+// @Summary      Send a duplicate downloads notification
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "success"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/duplicates [get]
+// @Security     ApiKeyAuth
+func (a *Actions) duplicates(input *common.ActionInput) (int, string) {
+	a.StarrQueue.Duplicates(input.Type)
+	return http.StatusOK, "Duplicate Downloads check triggered."
+}
+
 // @Description  Collects dashboard data and sends a notification.
 // @Summary      Send a dashboard notification
 // @Tags         Triggers
@@ -376,7 +431,7 @@ func (a *Actions) gaps(input *common.ActionInput) (int, string) {
 // @Summary      Start app-specific corruption check
 // @Tags         Triggers
 // @Produce      json
-// @Param        app  path   string  true  "app type to check" Enum(lidarr, prowlarr, radarr, readarr, sonarr)
+// @Param        app  path   string  true  "app type to check" Enum(lidarr, prowlarr, radarr, readarr, sonarr, plex, tautulli)
 // @Success      200  {object} apps.Respond.apiResponse{message=string} "success"
 // @Failure      400  {object} apps.Respond.apiResponse{message=string} "missing app"
 // @Failure      404  {object} string "bad token or api key"
@@ -397,7 +452,7 @@ func (a *Actions) corrupt(input *common.ActionInput, content string) (int, strin
 // @Summary      Start app-specific backup check
 // @Tags         Triggers
 // @Produce      json
-// @Param        app  path   string  true  "app type to check" Enum(lidarr, prowlarr, radarr, readarr, sonarr)
+// @Param        app  path   string  true  "app type to check" Enum(lidarr, prowlarr, radarr, readarr, sonarr, plex, tautulli)
 // @Success      200  {object} apps.Respond.apiResponse{message=string} "success"
 // @Failure      400  {object} apps.Respond.apiResponse{message=string} "missing app"
 // @Failure      404  {object} string "bad token or api key"
@@ -511,3 +566,24 @@ func (a *Actions) uploadlog(input *common.ActionInput, file string) (int, string
 
 	return http.StatusOK, fmt.Sprintf("Uploading %s log file.", file)
 }
+
+// @Description  Start a local workflow by name. The workflow runs in the background, check the log for results.
+// @Summary      Start workflow
+// @Tags         Triggers
+// @Produce      json
+// @Param        name  path   string  true  "Name of the workflow to run"
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "workflow started"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "unknown or disabled workflow"
+// @Failure      404  {object} string "bad token or api key"
+// @Failure      409  {object} apps.Respond.apiResponse{message=string} "workflow already running"
+// @Router       /api/trigger/workflow/{name} [get]
+// @Security     ApiKeyAuth
+func (a *Actions) workflow(input *common.ActionInput, content string) (int, string) {
+	if err := a.Workflows.Run(input, content); errors.Is(err, workflow.ErrRunning) {
+		return http.StatusConflict, err.Error()
+	} else if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	return http.StatusOK, "Workflow started: " + content
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/apps/apppkg/plex"
 	"github.com/Notifiarr/notifiarr/pkg/snapshot"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
 	"golift.io/cnfg"
@@ -44,11 +45,14 @@ const (
 )
 
 // New configures the library.
-func New(config *common.Config, plex *apps.PlexConfig) *Action {
+func New(config *common.Config, plexConfig *apps.PlexConfig) *Action {
+	// The previous sessions may be saved to disk, so session tracking does not start cold.
+	data.Persistent[*plex.Sessions]("plexCurrentSessions")
+
 	return &Action{
 		cmd: &cmd{
 			Config: config,
-			Plex:   plex,
+			Plex:   plexConfig,
 			sent:   make(map[string]struct{}),
 		},
 	}
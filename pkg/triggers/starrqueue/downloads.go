@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/apps"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
@@ -21,30 +22,38 @@ const maxQueuePayloadSize = 15
 
 type lidarrRecord struct {
 	*lidarr.QueueRecord
-	Name            string `json:"name"`
-	ArtistTitle     string `json:"artistTitle"`
-	ForeignAlbumID  string `json:"foreignAlbumId"`
-	ForeignArtistID string `json:"foreignArtistId"`
+	Name            string     `json:"name"`
+	ArtistTitle     string     `json:"artistTitle"`
+	ForeignAlbumID  string     `json:"foreignAlbumId"`
+	ForeignArtistID string     `json:"foreignArtistId"`
+	StuckSince      *time.Time `json:"stuckSince,omitempty"`
+	Stalled         bool       `json:"stalled,omitempty"`
 }
 
 type radarrRecord struct {
 	*radarr.QueueRecord
-	Name           string `json:"name"`
-	ForeignMovieID int64  `json:"foreignMovieId"`
+	Name           string     `json:"name"`
+	ForeignMovieID int64      `json:"foreignMovieId"`
+	StuckSince     *time.Time `json:"stuckSince,omitempty"`
+	Stalled        bool       `json:"stalled,omitempty"`
 }
 
 type sonarrRecord struct {
 	*sonarr.QueueRecord
-	Name            string `json:"name"`
-	ForeignSeriesID int64  `json:"foreignSeriesId"`
+	Name            string     `json:"name"`
+	ForeignSeriesID int64      `json:"foreignSeriesId"`
+	StuckSince      *time.Time `json:"stuckSince,omitempty"`
+	Stalled         bool       `json:"stalled,omitempty"`
 }
 
 type readarrRecord struct {
 	*readarr.QueueRecord
-	Name            string `json:"name"`
-	AuthorTitle     string `json:"authorTitle"`
-	ForeignBookID   string `json:"foreignBookId"`
-	ForeignAuthorID string `json:"foreignAuthorId"`
+	Name            string     `json:"name"`
+	AuthorTitle     string     `json:"authorTitle"`
+	ForeignBookID   string     `json:"foreignBookId"`
+	ForeignAuthorID string     `json:"foreignAuthorId"`
+	StuckSince      *time.Time `json:"stuckSince,omitempty"`
+	Stalled         bool       `json:"stalled,omitempty"`
 }
 
 // sendDownloadingQueues gathers the downloading queue items from cache and sends them.
@@ -0,0 +1,391 @@
+package starrqueue
+
+/* This file contains the procedures to find downloads queued in more than one starr app instance. */
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+const TrigDuplicateItems common.TriggerName = "Checking cached queues for duplicate downloads."
+
+// These are the ways duplicates are matched.
+const (
+	matchDownloadID = "downloadId"
+	matchTitle      = "title"
+)
+
+// DuplicatesPayload is what we send to the website when duplicate downloads are found.
+type DuplicatesPayload struct {
+	Duplicates []*Duplicate `json:"duplicates"`
+}
+
+// Duplicate is a download that is queued in more than one app instance.
+type Duplicate struct {
+	Match  string           `json:"match"` // downloadId or title
+	Key    string           `json:"key"`   // the matching download ID or title.
+	Copies []*DuplicateCopy `json:"copies"`
+}
+
+// DuplicateCopy is one app instance's copy of a duplicate download. The copies are sorted by priority, highest first.
+type DuplicateCopy struct {
+	App        string `json:"app"`
+	Instance   int    `json:"instance"`
+	Name       string `json:"name"`
+	Priority   int    `json:"priority"`
+	QueueID    int64  `json:"queueId"`
+	DownloadID string `json:"downloadId"`
+	Title      string `json:"title"`
+	Client     string `json:"downloadClient"`
+	Removed    bool   `json:"removed"`
+	Error      string `json:"error,omitempty"`
+	config     *apps.ExtraConfig
+	delete     func(ctx context.Context, queueID int64, opts *starr.QueueDeleteOpts) error
+}
+
+// Duplicates checks the cached queues for duplicate downloads and sends them to the website.
+func (a *Action) Duplicates(event website.EventType) {
+	a.cmd.Exec(&common.ActionInput{Type: event}, TrigDuplicateItems)
+}
+
+// sendDuplicates finds duplicate downloads in the cached queues, removes lower priority copies, and sends them.
+func (c *cmd) sendDuplicates(ctx context.Context, input *common.ActionInput) {
+	copies := append(c.lidarrCopies(), c.radarrCopies()...)
+	copies = append(copies, c.readarrCopies()...)
+	copies = append(copies, c.sonarrCopies()...)
+
+	dupes := findDuplicates(c.skipRemoved(copies))
+	if len(dupes) == 0 {
+		c.sentDupes = ""
+		c.Debugf("[%s requested] No duplicate downloads found in %d queued items.", input.Type, len(copies))
+
+		return
+	}
+
+	removed := 0
+
+	for _, dupe := range dupes {
+		removed += c.removeDuplicate(ctx, input, dupe)
+	}
+
+	// The timer only sends duplicates when they change. Other requests always send them.
+	sent := signature(dupes)
+	if input.Type == website.EventCron && removed == 0 && sent == c.sentDupes {
+		c.Debugf("[%s requested] Duplicate downloads did not change: %d", input.Type, len(dupes))
+		return
+	}
+
+	c.sentDupes = sent
+
+	c.SendData(&website.Request{
+		Route:      website.DupesRoute,
+		Event:      input.Type,
+		LogPayload: true,
+		LogMsg:     fmt.Sprintf("Duplicate Downloads: %d, removed copies: %d", len(dupes), removed),
+		Payload:    &DuplicatesPayload{Duplicates: dupes},
+	})
+}
+
+// skipRemoved filters out copies that were removed, but are still in the cached queue.
+// It also forgets removed copies that are no longer in the cached queue.
+func (c *cmd) skipRemoved(copies []*DuplicateCopy) []*DuplicateCopy {
+	output := []*DuplicateCopy{}
+	seen := make(map[string]struct{})
+
+	for _, dupe := range copies {
+		if _, removed := c.removedDupes[dupe.key()]; removed {
+			seen[dupe.key()] = struct{}{}
+			continue
+		}
+
+		output = append(output, dupe)
+	}
+
+	c.removedDupes = seen
+
+	return output
+}
+
+// key identifies a queue item in an app instance.
+func (d *DuplicateCopy) key() string {
+	return fmt.Sprint(d.App, d.Instance, "-", d.QueueID)
+}
+
+// signature identifies a list of duplicates and their copies, so an unchanged list is not sent again.
+func signature(dupes []*Duplicate) string {
+	keys := []string{}
+
+	for _, dupe := range dupes {
+		for _, dupeCopy := range dupe.Copies {
+			keys = append(keys, dupe.Match+":"+dupe.Key+":"+dupeCopy.key())
+		}
+	}
+
+	return strings.Join(keys, "\n")
+}
+
+// findDuplicates groups the copies by download ID, then the rest by title.
+// A group is a duplicate when it has copies in more than one app instance.
+func findDuplicates(copies []*DuplicateCopy) []*Duplicate {
+	dupes, leftover := group(copies, matchDownloadID, func(c *DuplicateCopy) string { return c.DownloadID })
+	titles, _ := group(leftover, matchTitle, func(c *DuplicateCopy) string {
+		return strings.ToLower(strings.TrimSpace(c.Title))
+	})
+	dupes = append(dupes, titles...)
+
+	for _, dupe := range dupes {
+		sort.SliceStable(dupe.Copies, func(i, j int) bool { return dupe.Copies[i].Priority > dupe.Copies[j].Priority })
+	}
+
+	sort.Slice(dupes, func(i, j int) bool {
+		if dupes[i].Match == dupes[j].Match {
+			return dupes[i].Key < dupes[j].Key
+		}
+
+		return dupes[i].Match < dupes[j].Match
+	})
+
+	return dupes
+}
+
+// group returns the groups with copies in more than one app instance, and the copies that are not in one.
+func group(copies []*DuplicateCopy, match string, key func(*DuplicateCopy) string) ([]*Duplicate, []*DuplicateCopy) {
+	groups := make(map[string][]*DuplicateCopy)
+	leftover := []*DuplicateCopy{}
+
+	for _, dupe := range copies {
+		if k := key(dupe); k != "" {
+			groups[k] = append(groups[k], dupe)
+		} else {
+			leftover = append(leftover, dupe)
+		}
+	}
+
+	dupes := []*Duplicate{}
+
+	for k, group := range groups {
+		instances := make(map[string]struct{})
+		for _, dupe := range group {
+			instances[dupe.App+strconv.Itoa(dupe.Instance)] = struct{}{}
+		}
+
+		if len(instances) > 1 {
+			dupes = append(dupes, &Duplicate{Match: match, Key: k, Copies: group})
+		} else {
+			leftover = append(leftover, group...)
+		}
+	}
+
+	return dupes, leftover
+}
+
+// removeDuplicate removes the copies with a lower priority than the first copy, from instances that allow it.
+// Nothing is removed when the highest priority is shared, because there is no clear copy to keep.
+// Copies with the same download ID as the kept copy are only removed from the queue, not the download client.
+func (c *cmd) removeDuplicate(ctx context.Context, input *common.ActionInput, dupe *Duplicate) int {
+	keep := dupe.Copies[0]
+	if dupe.Copies[1].Priority == keep.Priority {
+		return 0
+	}
+
+	removed := 0
+
+	for _, other := range dupe.Copies[1:] {
+		if !other.config.RemoveDupes {
+			continue
+		}
+
+		if !other.config.DelOK() {
+			other.Error = apps.ErrRateLimit.Error()
+			continue
+		}
+
+		removeFromClient := other.DownloadID != keep.DownloadID
+
+		err := other.delete(ctx, other.QueueID, &starr.QueueDeleteOpts{
+			RemoveFromClient: &removeFromClient,
+			SkipRedownload:   true,
+		})
+		if err != nil {
+			other.Error = err.Error()
+			c.Errorf("[%s requested] Removing duplicate download from %s (%d) queue item %d '%s': %v",
+				input.Type, other.App, other.Instance, other.QueueID, other.Title, err)
+
+			continue
+		}
+
+		other.Removed = true
+		c.removedDupes[other.key()] = struct{}{}
+		removed++
+
+		c.Printf("[%s requested] Removed duplicate download from %s (%d) queue item %d '%s', kept in %s (%d)",
+			input.Type, other.App, other.Instance, other.QueueID, other.Title, keep.App, keep.Instance)
+	}
+
+	return removed
+}
+
+// The following procedures collect one copy of each download in every cached queue.
+// Only instances with stuck items enabled on the website are included.
+
+func (c *cmd) lidarrCopies() []*DuplicateCopy {
+	copies := []*DuplicateCopy{}
+	ci := clientinfo.Get()
+
+	for idx, app := range c.Apps.Lidarr {
+		item := data.GetWithID("lidarr", idx)
+		if !app.Enabled() || ci == nil || !ci.Actions.Apps.Lidarr.Stuck(idx+1) || item == nil || item.Data == nil {
+			continue
+		}
+
+		queue, _ := item.Data.(*lidarr.Queue)
+		repeatStomper := make(map[string]struct{})
+
+		for _, record := range queue.Records {
+			if _, exists := repeatStomper[record.DownloadID]; exists && record.DownloadID != "" {
+				continue
+			}
+
+			repeatStomper[record.DownloadID] = struct{}{}
+			copies = append(copies, &DuplicateCopy{
+				App:        "Lidarr",
+				Instance:   idx + 1,
+				Name:       app.Name,
+				Priority:   app.Priority,
+				QueueID:    record.ID,
+				DownloadID: record.DownloadID,
+				Title:      record.Title,
+				Client:     record.DownloadClient,
+				config:     &app.ExtraConfig,
+				delete:     app.DeleteQueueContext,
+			})
+		}
+	}
+
+	return copies
+}
+
+func (c *cmd) radarrCopies() []*DuplicateCopy {
+	copies := []*DuplicateCopy{}
+	ci := clientinfo.Get()
+
+	for idx, app := range c.Apps.Radarr {
+		item := data.GetWithID("radarr", idx)
+		if !app.Enabled() || ci == nil || !ci.Actions.Apps.Radarr.Stuck(idx+1) || item == nil || item.Data == nil {
+			continue
+		}
+
+		queue, _ := item.Data.(*radarr.Queue)
+		repeatStomper := make(map[string]struct{})
+
+		for _, record := range queue.Records {
+			if _, exists := repeatStomper[record.DownloadID]; exists && record.DownloadID != "" {
+				continue
+			}
+
+			repeatStomper[record.DownloadID] = struct{}{}
+			copies = append(copies, &DuplicateCopy{
+				App:        "Radarr",
+				Instance:   idx + 1,
+				Name:       app.Name,
+				Priority:   app.Priority,
+				QueueID:    record.ID,
+				DownloadID: record.DownloadID,
+				Title:      record.Title,
+				Client:     record.DownloadClient,
+				config:     &app.ExtraConfig,
+				delete:     app.DeleteQueueContext,
+			})
+		}
+	}
+
+	return copies
+}
+
+func (c *cmd) readarrCopies() []*DuplicateCopy {
+	copies := []*DuplicateCopy{}
+	ci := clientinfo.Get()
+
+	for idx, app := range c.Apps.Readarr {
+		item := data.GetWithID("readarr", idx)
+		if !app.Enabled() || ci == nil || !ci.Actions.Apps.Readarr.Stuck(idx+1) || item == nil || item.Data == nil {
+			continue
+		}
+
+		queue, _ := item.Data.(*readarr.Queue)
+		repeatStomper := make(map[string]struct{})
+
+		for _, record := range queue.Records {
+			if _, exists := repeatStomper[record.DownloadID]; exists && record.DownloadID != "" {
+				continue
+			}
+
+			repeatStomper[record.DownloadID] = struct{}{}
+			copies = append(copies, &DuplicateCopy{
+				App:        "Readarr",
+				Instance:   idx + 1,
+				Name:       app.Name,
+				Priority:   app.Priority,
+				QueueID:    record.ID,
+				DownloadID: record.DownloadID,
+				Title:      record.Title,
+				Client:     record.DownloadClient,
+				config:     &app.ExtraConfig,
+				delete:     app.DeleteQueueContext,
+			})
+		}
+	}
+
+	return copies
+}
+
+func (c *cmd) sonarrCopies() []*DuplicateCopy {
+	copies := []*DuplicateCopy{}
+	ci := clientinfo.Get()
+
+	for idx, app := range c.Apps.Sonarr {
+		item := data.GetWithID("sonarr", idx)
+		if !app.Enabled() || ci == nil || !ci.Actions.Apps.Sonarr.Stuck(idx+1) || item == nil || item.Data == nil {
+			continue
+		}
+
+		queue, _ := item.Data.(*sonarr.Queue)
+		repeatStomper := make(map[string]struct{})
+
+		for _, record := range queue.Records {
+			if _, exists := repeatStomper[record.DownloadID]; exists && record.DownloadID != "" {
+				continue
+			}
+
+			repeatStomper[record.DownloadID] = struct{}{}
+			copies = append(copies, &DuplicateCopy{
+				App:        "Sonarr",
+				Instance:   idx + 1,
+				Name:       app.Name,
+				Priority:   app.Priority,
+				QueueID:    record.ID,
+				DownloadID: record.DownloadID,
+				Title:      record.Title,
+				Client:     record.DownloadClient,
+				config:     &app.ExtraConfig,
+				delete:     app.DeleteQueueContext,
+			})
+		}
+	}
+
+	return copies
+}
@@ -0,0 +1,394 @@
+package starrqueue
+
+/* This file contains the procedures to record queue item lifecycle events, and compute completion analytics. */
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/gorilla/mux"
+	"golift.io/cnfg"
+)
+
+// ErrNoHistory is returned by the history handler when an app instance has no queue history.
+var ErrNoHistory = errors.New("no queue history for app instance")
+
+// historyKey is the data store key for queue history. It's saved to disk when the data store persists.
+const historyKey = "queueHistory"
+
+// maxHistoryEvents is how many events are kept for each app instance. Analytics are computed from these.
+const maxHistoryEvents = 1000
+
+// These are the queue item lifecycle events.
+const (
+	EventAdded       = "added"
+	EventProgressing = "progressing"
+	EventCompleted   = "completed"
+	EventFailed      = "failed"
+	EventRemoved     = "removed"
+)
+
+// historyLock protects the queue history. It's written from the timer loop and read by the API and dashboard.
+//
+//nolint:gochecknoglobals
+var historyLock sync.Mutex
+
+// QueueEvent is a change in a queue item's lifecycle.
+type QueueEvent struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	DownloadID string    `json:"downloadId"`
+	Title      string    `json:"title"`
+	Indexer    string    `json:"indexer"`
+	Client     string    `json:"downloadClient"`
+	// Elapsed is the time since the item was added. Zero if it was already queued when tracking started.
+	Elapsed cnfg.Duration `json:"elapsed"`
+}
+
+// QueuedItem is the last known state of a download in the queue.
+type QueuedItem struct {
+	Added       time.Time `json:"added"`
+	Title       string    `json:"title"`
+	Indexer     string    `json:"indexer"`
+	Client      string    `json:"downloadClient"`
+	Sizeleft    float64   `json:"sizeleft"`
+	Existing    bool      `json:"existing"` // already queued when tracking started, so the added time is not known.
+	Progressing bool      `json:"progressing"`
+	Finished    bool      `json:"finished"` // completed or failed.
+}
+
+// QueueHistory is the lifecycle history of queue items for one app instance.
+type QueueHistory struct {
+	Since  time.Time              `json:"since"`  // first time the queue was checked.
+	Events []*QueueEvent          `json:"events"` // oldest first.
+	Queued map[string]*QueuedItem `json:"queued"` // by download ID.
+}
+
+// Stats are the outcomes of downloads from an indexer or download client.
+type Stats struct {
+	Added     int `json:"added"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+	Removed   int `json:"removed"` // removed from the queue before completing or failing.
+	// SuccessRate is the percentage of finished (completed or failed) downloads that completed.
+	SuccessRate float64 `json:"successRate"`
+	// MedianCompletion is the median time from added to completed.
+	MedianCompletion cnfg.Duration `json:"medianCompletion"`
+	durations        []time.Duration
+}
+
+// Analytics are the computed queue history statistics for an app instance.
+type Analytics struct {
+	App      string            `json:"app"`
+	Instance int               `json:"instance"`
+	Since    time.Time         `json:"since"`
+	Queued   int               `json:"queued"`
+	Total    *Stats            `json:"total"`
+	Indexers map[string]*Stats `json:"indexers"`
+	Clients  map[string]*Stats `json:"downloadClients"`
+	Events   []*QueueEvent     `json:"events,omitempty"`
+}
+
+// historyEntry is the part of a queue record needed to track its lifecycle.
+type historyEntry struct {
+	DownloadID string
+	Title      string
+	Status     string
+	State      string
+	Indexer    string
+	Client     string
+	Sizeleft   float64
+}
+
+// recordHistory compares a fresh queue with the last one, and records lifecycle events for the changes.
+func (c *cmd) recordHistory(app string, instance int, entries []*historyEntry) {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+
+	// The saved history is never changed, because the data store API may be encoding it. Change and save a copy.
+	all := maps.Clone(histories())
+	key := app + strconv.Itoa(instance)
+	now := time.Now()
+	history := all[key]
+	existing := history == nil // the first check cannot tell when items were added.
+
+	if existing {
+		history = &QueueHistory{Since: now, Queued: make(map[string]*QueuedItem)}
+	} else {
+		history = history.copy()
+	}
+
+	all[key] = history
+
+	seen := make(map[string]struct{})
+
+	for _, entry := range entries {
+		if _, ok := seen[entry.DownloadID]; ok || entry.DownloadID == "" {
+			continue
+		}
+
+		seen[entry.DownloadID] = struct{}{}
+		history.update(now, entry, existing)
+	}
+
+	for downloadID, item := range history.Queued {
+		if _, ok := seen[downloadID]; !ok {
+			history.add(now, EventRemoved, downloadID, item)
+			delete(history.Queued, downloadID)
+		}
+	}
+
+	if len(history.Events) > maxHistoryEvents {
+		history.Events = history.Events[len(history.Events)-maxHistoryEvents:]
+	}
+
+	data.Save(historyKey, all)
+
+	c.Debugf("Queue history for %s (%d): %d queued, %d events", app, instance, len(history.Queued), len(history.Events))
+}
+
+// copy returns a copy of the history that can be changed. Events are not changed once added, so they are shared.
+func (h *QueueHistory) copy() *QueueHistory {
+	queued := make(map[string]*QueuedItem, len(h.Queued))
+
+	for downloadID, item := range h.Queued {
+		copied := *item
+		queued[downloadID] = &copied
+	}
+
+	return &QueueHistory{Since: h.Since, Events: append([]*QueueEvent{}, h.Events...), Queued: queued}
+}
+
+// update records the events for a single queue item.
+func (h *QueueHistory) update(now time.Time, entry *historyEntry, existing bool) {
+	item := h.Queued[entry.DownloadID]
+	if item == nil {
+		item = &QueuedItem{Added: now, Sizeleft: entry.Sizeleft, Existing: existing}
+		h.Queued[entry.DownloadID] = item
+
+		if !existing {
+			h.add(now, EventAdded, entry.DownloadID, item)
+		}
+	}
+
+	// These may not be set when the item is first queued.
+	item.Title = entry.Title
+	item.Indexer = entry.Indexer
+	item.Client = entry.Client
+
+	if !item.Progressing && entry.Sizeleft < item.Sizeleft {
+		item.Progressing = true
+		h.add(now, EventProgressing, entry.DownloadID, item)
+	}
+
+	item.Sizeleft = entry.Sizeleft
+
+	if item.Finished {
+		return
+	}
+
+	switch status, state := strings.ToLower(entry.Status), strings.ToLower(entry.State); {
+	case status == failed || status == errorstr || state == "failed" || state == "failedpending":
+		item.Finished = true
+		h.add(now, EventFailed, entry.DownloadID, item)
+	case status == completed:
+		item.Finished = true
+		h.add(now, EventCompleted, entry.DownloadID, item)
+	}
+}
+
+// add appends an event for a queued item.
+func (h *QueueHistory) add(now time.Time, event, downloadID string, item *QueuedItem) {
+	record := &QueueEvent{
+		Time:       now,
+		Event:      event,
+		DownloadID: downloadID,
+		Title:      item.Title,
+		Indexer:    item.Indexer,
+		Client:     item.Client,
+	}
+
+	if !item.Existing {
+		record.Elapsed.Duration = now.Sub(item.Added).Round(time.Second)
+	}
+
+	h.Events = append(h.Events, record)
+}
+
+// histories returns the saved queue history for every app instance. Must be called while holding the history lock.
+// The returned history must not be changed; recordHistory saves a changed copy.
+func histories() map[string]*QueueHistory {
+	if item := data.Get(historyKey); item != nil {
+		if all, ok := item.Data.(map[string]*QueueHistory); ok {
+			return all
+		}
+	}
+
+	return make(map[string]*QueueHistory)
+}
+
+// Analytics returns the queue statistics for every app instance, sorted by app and instance. Events are not included.
+func (a *Action) Analytics() []*Analytics {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+
+	output := []*Analytics{}
+
+	for key, history := range histories() {
+		app := strings.TrimRight(key, "0123456789")
+		instance, _ := strconv.Atoi(strings.TrimPrefix(key, app))
+		output = append(output, history.analytics(app, instance))
+	}
+
+	sort.Slice(output, func(i, j int) bool {
+		if output[i].App == output[j].App {
+			return output[i].Instance < output[j].Instance
+		}
+
+		return output[i].App < output[j].App
+	})
+
+	return output
+}
+
+// analytics computes the statistics for the events in an instance's history.
+func (h *QueueHistory) analytics(app string, instance int) *Analytics {
+	output := &Analytics{
+		App:      app,
+		Instance: instance,
+		Since:    h.Since,
+		Queued:   len(h.Queued),
+		Total:    &Stats{},
+		Indexers: make(map[string]*Stats),
+		Clients:  make(map[string]*Stats),
+	}
+
+	// Removed events for finished items are normal; only count removals of unfinished items.
+	finished := make(map[string]struct{})
+
+	for _, event := range h.Events {
+		indexer, client := output.Indexers[event.Indexer], output.Clients[event.Client]
+		if indexer == nil {
+			indexer = &Stats{}
+			output.Indexers[event.Indexer] = indexer
+		}
+
+		if client == nil {
+			client = &Stats{}
+			output.Clients[event.Client] = client
+		}
+
+		if event.Event == EventCompleted || event.Event == EventFailed {
+			finished[event.DownloadID] = struct{}{}
+		} else if _, ok := finished[event.DownloadID]; ok && event.Event == EventRemoved {
+			continue
+		}
+
+		for _, stats := range []*Stats{output.Total, indexer, client} {
+			stats.count(event)
+		}
+	}
+
+	for _, stats := range output.Indexers {
+		stats.compute()
+	}
+
+	for _, stats := range output.Clients {
+		stats.compute()
+	}
+
+	output.Total.compute()
+
+	return output
+}
+
+// count adds an event to the statistics.
+func (s *Stats) count(event *QueueEvent) {
+	switch event.Event {
+	case EventAdded:
+		s.Added++
+	case EventCompleted:
+		s.Completed++
+
+		if event.Elapsed.Duration > 0 {
+			s.durations = append(s.durations, event.Elapsed.Duration)
+		}
+	case EventFailed:
+		s.Failed++
+	case EventRemoved:
+		s.Removed++
+	}
+}
+
+// compute the success rate and median completion time from the counted events.
+func (s *Stats) compute() {
+	if finished := s.Completed + s.Failed; finished > 0 {
+		s.SuccessRate = float64(s.Completed*10000/finished) / 100 //nolint:mnd // two decimal places.
+	}
+
+	if len(s.durations) == 0 {
+		return
+	}
+
+	sort.Slice(s.durations, func(i, j int) bool { return s.durations[i] < s.durations[j] })
+
+	if mid := len(s.durations) / 2; len(s.durations)%2 == 1 {
+		s.MedianCompletion.Duration = s.durations[mid]
+	} else {
+		s.MedianCompletion.Duration = (s.durations[mid-1] + s.durations[mid]) / 2
+	}
+
+	s.durations = nil
+}
+
+// @Description  Returns queue statistics and lifecycle events for a single Starr app instance.
+// @Summary      Retrieve queue history for an instance.
+// @Tags         Triggers
+// @Produce      json
+// @Param        app       path   string  true  "app name: lidarr, radarr, readarr, sonarr"
+// @Param        instance  path   int64   true  "instance ID"
+// @Success      200  {object} apps.Respond.apiResponse{message=Analytics} "statistics and events"
+// @Failure      404  {object} apps.Respond.apiResponse{message=string} "no history, or bad token or api key"
+// @Router       /api/queues/history/{app}/{instance} [get]
+// @Security     ApiKeyAuth
+func _() {}
+
+// HistoryHandler returns queue analytics for one or all app instances.
+// @Description  Returns queue statistics for all Starr app instances: success rates and median
+// @Description  completion times per indexer and per download client. Events are not included.
+// @Summary      Retrieve queue analytics.
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=[]Analytics} "statistics for each instance"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/queues/history [get]
+// @Security     ApiKeyAuth
+func (a *Action) HistoryHandler(req *http.Request) (int, interface{}) {
+	vars := mux.Vars(req)
+	if vars["app"] == "" {
+		return http.StatusOK, a.Analytics()
+	}
+
+	app := strings.ToUpper(vars["app"][:1]) + strings.ToLower(vars["app"][1:])
+	instance, _ := strconv.Atoi(vars["instance"])
+
+	historyLock.Lock()
+	defer historyLock.Unlock()
+
+	history := histories()[app+strconv.Itoa(instance)]
+	if history == nil {
+		return http.StatusNotFound, ErrNoHistory
+	}
+
+	output := history.analytics(app, instance)
+	output.Events = append([]*QueueEvent{}, history.Events...)
+
+	return http.StatusOK, output
+}
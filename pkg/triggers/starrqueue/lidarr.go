@@ -36,10 +36,22 @@ func (app *lidarrApp) storeQueue(ctx context.Context, input *common.ActionInput)
 		return
 	}
 
-	for _, record := range queue.Records {
+	entries := make([]*historyEntry, len(queue.Records))
+
+	for idx, record := range queue.Records {
 		record.Quality = nil
+		entries[idx] = &historyEntry{
+			DownloadID: record.DownloadID,
+			Title:      record.Title,
+			Status:     record.Status,
+			State:      record.TrackedDownloadState,
+			Indexer:    record.Indexer,
+			Client:     record.DownloadClient,
+			Sizeleft:   record.Sizeleft,
+		}
 	}
 
+	app.cmd.recordHistory("Lidarr", app.idx+1, entries)
 	app.cmd.Debugf("[%s requested] Stored Lidarr Queue (%d items), instance %d %s",
 		input.Type, len(queue.Records), app.idx+1, app.app.Name)
 	data.SaveWithID("lidarr", app.idx, queue)
@@ -36,12 +36,24 @@ func (app *radarrApp) storeQueue(ctx context.Context, input *common.ActionInput)
 		return
 	}
 
-	for _, item := range queue.Records {
+	entries := make([]*historyEntry, len(queue.Records))
+
+	for idx, item := range queue.Records {
 		item.Quality = nil
 		item.CustomFormats = nil
 		item.Languages = nil
+		entries[idx] = &historyEntry{
+			DownloadID: item.DownloadID,
+			Title:      item.Title,
+			Status:     item.Status,
+			State:      item.TrackedDownloadState,
+			Indexer:    item.Indexer,
+			Client:     item.DownloadClient,
+			Sizeleft:   item.Sizeleft,
+		}
 	}
 
+	app.cmd.recordHistory("Radarr", app.idx+1, entries)
 	app.cmd.Debugf("[%s requested] Stored Radarr Queue (%d items), instance %d %s",
 		input.Type, len(queue.Records), app.idx+1, app.app.Name)
 	data.SaveWithID("radarr", app.idx, queue)
@@ -0,0 +1,130 @@
+package starrqueue
+
+/* This file contains the procedures to remove stuck queue items with local remediation policies. */
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"golift.io/cnfg"
+	"golift.io/starr"
+)
+
+// Remediated is an action taken on a stuck queue item by a local remediation policy.
+// In dry run mode, it's the action that would have been taken. These are sent with the stuck items.
+type Remediated struct {
+	QueueID    int64         `json:"queueId"`
+	DownloadID string        `json:"downloadId"`
+	Title      string        `json:"title"`
+	Status     string        `json:"status"`
+	StuckFor   cnfg.Duration `json:"stuckFor"`
+	Remove     bool          `json:"remove"`
+	Blocklist  bool          `json:"blocklist"`
+	Search     bool          `json:"search"`
+	DryRun     bool          `json:"dryRun"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// stuckRecord is the part of a starr queue record that remediation needs.
+type stuckRecord struct {
+	ID         int64
+	DownloadID string
+	Title      string
+	Status     string
+	State      string
+	tracked    *Tracked
+}
+
+// remediator removes stuck queue items from one starr app instance.
+type remediator struct {
+	app      string
+	instance int
+	config   *apps.ExtraConfig
+	delete   func(ctx context.Context, queueID int64, opts *starr.QueueDeleteOpts) error
+}
+
+// newStuckRecord returns a stuck record for remediation. Items that are only stalled get the stalled state.
+func newStuckRecord(id int64, downloadID, title, status, state string, stuck bool, tracked *Tracked) *stuckRecord {
+	if !stuck {
+		state = stalled
+	}
+
+	return &stuckRecord{ID: id, DownloadID: downloadID, Title: title, Status: status, State: state, tracked: tracked}
+}
+
+// remediate applies the instance's remediation policies to its stuck items.
+func (c *cmd) remediate(ctx context.Context, input *common.ActionInput,
+	rem *remediator, records []*stuckRecord,
+) []*Remediated {
+	now := time.Now()
+	output := []*Remediated{}
+
+	for _, record := range records {
+		stuckFor := now.Sub(record.tracked.StuckSince)
+
+		policy := rem.config.Remediation(record.Status, record.State, stuckFor)
+		if policy == nil || record.tracked.Remediated {
+			continue
+		}
+
+		if !policy.Remove && !policy.Blocklist {
+			// Deleting with neither only drops the item from the queue, and the client keeps downloading it.
+			c.Debugf("[%s requested] Skipping %s (%d) stuck queue item %d '%s': policy has no remove or blocklist",
+				input.Type, rem.app, rem.instance, record.ID, record.Title)
+			continue
+		}
+
+		result := &Remediated{
+			QueueID:    record.ID,
+			DownloadID: record.DownloadID,
+			Title:      record.Title,
+			Status:     record.Status,
+			StuckFor:   cnfg.Duration{Duration: stuckFor.Round(time.Second)},
+			Remove:     policy.Remove,
+			Blocklist:  policy.Blocklist,
+			Search:     policy.Blocklist && policy.Search,
+			DryRun:     policy.DryRun,
+		}
+		output = append(output, result)
+
+		if err := c.remediateRecord(ctx, rem, result); err != nil {
+			result.Error = err.Error()
+			c.Errorf("[%s requested] Remediating %s (%d) stuck queue item %d '%s' (stuck %s): %v",
+				input.Type, rem.app, rem.instance, record.ID, record.Title, result.StuckFor, err)
+
+			continue
+		}
+
+		record.tracked.Remediated = true
+		c.Printf("[%s requested] Remediated %s (%d) stuck queue item %d '%s' (stuck %s), status: %s, "+
+			"remove: %v, blocklist: %v, search: %v, dry run: %v", input.Type, rem.app, rem.instance, record.ID,
+			record.Title, result.StuckFor, record.Status, result.Remove, result.Blocklist, result.Search, result.DryRun)
+	}
+
+	return output
+}
+
+// remediateRecord deletes a queue item, unless it's a dry run.
+func (c *cmd) remediateRecord(ctx context.Context, rem *remediator, result *Remediated) error {
+	if result.DryRun {
+		return nil
+	}
+
+	if !rem.config.DelOK() {
+		return apps.ErrRateLimit
+	}
+
+	err := rem.delete(ctx, result.QueueID, &starr.QueueDeleteOpts{
+		RemoveFromClient: &result.Remove,
+		BlockList:        result.Blocklist,
+		SkipRedownload:   !result.Search,
+	})
+	if err != nil {
+		return fmt.Errorf("deleting queue item: %w", err)
+	}
+
+	return nil
+}
@@ -4,8 +4,13 @@ import (
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
 	"golift.io/cnfg"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
 )
 
 /* This file contains the procedures to send stuck download queue items to notifiarr. */
@@ -18,7 +23,12 @@ type Action struct {
 type cmd struct {
 	*common.Config
 	// We set empty to true after we send 1 "empty downloads" payload.
-	empty bool
+	empty       bool
+	stuckConfig *Config
+	// removedDupes are duplicate queue items that were removed, but may still be in the cached queues.
+	removedDupes map[string]struct{}
+	// sentDupes identifies the last duplicates sent by the timer, so they are only sent again when they change.
+	sentDupes string
 }
 
 const (
@@ -53,8 +63,20 @@ type QueuesPaylod struct {
 }
 
 // New configures the library.
-func New(config *common.Config) *Action {
-	return &Action{cmd: &cmd{Config: config}}
+func New(config *common.Config, stuckConfig *Config) *Action {
+	// The stored queues may be saved to disk, so stuck items are found sooner after a restart.
+	data.Persistent[*lidarr.Queue]("lidarr")
+	data.Persistent[*radarr.Queue]("radarr")
+	data.Persistent[*readarr.Queue]("readarr")
+	data.Persistent[*sonarr.Queue]("sonarr")
+	data.Persistent[map[string]*Tracked](trackerKey)
+	data.Persistent[map[string]*QueueHistory](historyKey)
+
+	if stuckConfig == nil {
+		stuckConfig = &Config{}
+	}
+
+	return &Action{cmd: &cmd{Config: config, stuckConfig: stuckConfig}}
 }
 
 // Run initializes the library.
@@ -71,6 +93,12 @@ func (a *Action) Create() {
 			C:    make(chan *common.ActionInput, 1),
 			D:    cnfg.Duration{Duration: stuckDuration},
 		})
+		a.cmd.Add(&common.Action{
+			Name: TrigDuplicateItems,
+			Fn:   a.cmd.sendDuplicates,
+			C:    make(chan *common.ActionInput, 1),
+			D:    cnfg.Duration{Duration: stuckDuration},
+		})
 
 		// Only enable this timer if the user is a patron.
 		if ci := clientinfo.Get(); ci != nil && ci.IsPatron() {
@@ -87,9 +115,10 @@ func (a *Action) Create() {
 
 // listItem is data formatted for sending a json payload to the website.
 type listItem struct {
-	Name  string      `json:"name"`
-	Queue interface{} `json:"queue"`
-	Total int         `json:"total"`
+	Name       string        `json:"name"`
+	Queue      interface{}   `json:"queue"`
+	Total      int           `json:"total"`
+	Remediated []*Remediated `json:"remediated,omitempty"`
 }
 
 // itemList stores an instance->queue map.
@@ -3,7 +3,6 @@ package starrqueue
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
@@ -23,10 +22,10 @@ func (a *Action) StuckItems(event website.EventType) {
 
 // sendStuckQueues gathers the stuck queue from cache and sends them.
 func (c *cmd) sendStuckQueues(ctx context.Context, input *common.ActionInput) {
-	lidarr := c.getFinishedItemsLidarr(ctx)
-	radarr := c.getFinishedItemsRadarr(ctx)
-	readarr := c.getFinishedItemsReadarr(ctx)
-	sonarr := c.getFinishedItemsSonarr(ctx)
+	lidarr := c.getFinishedItemsLidarr(ctx, input)
+	radarr := c.getFinishedItemsRadarr(ctx, input)
+	readarr := c.getFinishedItemsReadarr(ctx, input)
+	sonarr := c.getFinishedItemsSonarr(ctx, input)
 
 	if lidarr.Empty() && radarr.Empty() && readarr.Empty() && sonarr.Empty() {
 		c.Debugf("[%s requested] No stuck items found.", input.Type)
@@ -48,7 +47,7 @@ func (c *cmd) sendStuckQueues(ctx context.Context, input *common.ActionInput) {
 	})
 }
 
-func (c *cmd) getFinishedItemsLidarr(_ context.Context) itemList { //nolint:cyclop
+func (c *cmd) getFinishedItemsLidarr(ctx context.Context, input *common.ActionInput) itemList { //nolint:cyclop
 	stuck := make(itemList)
 
 	for idx, app := range c.Apps.Lidarr {
@@ -65,22 +64,45 @@ func (c *cmd) getFinishedItemsLidarr(_ context.Context) itemList { //nolint:cycl
 		queue, _ := item.Data.(*lidarr.Queue)
 		instance := idx + 1
 		appqueue := []*lidarrRecord{}
+		records := []*stuckRecord{}
+		tracker := c.tracker("Lidarr", instance, item.Time)
 		// repeatStomper is used to collapse duplicate download IDs.
 		repeatStomper := make(map[string]struct{})
 
 		for _, item := range queue.Records {
-			if s := strings.ToLower(item.Status); s != completed && s != warning &&
-				s != failed && s != errorstr && item.ErrorMessage == "" && len(item.StatusMessages) == 0 {
-				continue
-			} else if _, exists := repeatStomper[item.DownloadID]; exists {
+			if _, exists := repeatStomper[item.DownloadID]; exists {
 				continue
 			}
 
 			repeatStomper[item.DownloadID] = struct{}{}
-			appqueue = append(appqueue, &lidarrRecord{QueueRecord: item}) //nolint:wsl
+			stuck := stuckStatus(item.Status, item.ErrorMessage, len(item.StatusMessages))
+
+			tracked := tracker.check(item.DownloadID, item.Sizeleft, stuck)
+			if tracked.StuckSince.IsZero() {
+				continue
+			}
+
+			records = append(records, newStuckRecord(item.ID, item.DownloadID, item.Title,
+				item.Status, item.TrackedDownloadState, stuck, tracked))
+
+			if stuckSince := tracked.StuckSince; tracker.report(tracked) {
+				appqueue = append(appqueue, &lidarrRecord{ //nolint:wsl
+					QueueRecord: item,
+					StuckSince:  &stuckSince,
+					Stalled:     tracked.Stalled,
+				})
+			}
 		}
 
-		stuck[instance] = listItem{Name: app.Name, Queue: appqueue, Total: queue.TotalRecords}
+		remediated := c.remediate(ctx, input, &remediator{
+			app:      "Lidarr",
+			instance: instance,
+			config:   &app.ExtraConfig,
+			delete:   app.DeleteQueueContext,
+		}, records)
+		tracker.save()
+
+		stuck[instance] = listItem{Name: app.Name, Queue: appqueue, Total: queue.TotalRecords, Remediated: remediated}
 		c.Debugf("Checking Lidarr (%d) Queue for Stuck Items, queue size: %d, stuck: %d",
 			instance, len(queue.Records), len(appqueue))
 	}
@@ -88,7 +110,7 @@ func (c *cmd) getFinishedItemsLidarr(_ context.Context) itemList { //nolint:cycl
 	return stuck
 }
 
-func (c *cmd) getFinishedItemsRadarr(_ context.Context) itemList { //nolint:cyclop
+func (c *cmd) getFinishedItemsRadarr(ctx context.Context, input *common.ActionInput) itemList { //nolint:cyclop
 	stuck := make(itemList)
 
 	for idx, app := range c.Apps.Radarr {
@@ -105,22 +127,45 @@ func (c *cmd) getFinishedItemsRadarr(_ context.Context) itemList { //nolint:cycl
 		queue, _ := item.Data.(*radarr.Queue)
 		instance := idx + 1
 		appqueue := []*radarrRecord{}
+		records := []*stuckRecord{}
+		tracker := c.tracker("Radarr", instance, item.Time)
 		// repeatStomper is used to collapse duplicate download IDs.
 		repeatStomper := make(map[string]struct{})
 
 		for _, item := range queue.Records {
-			if s := strings.ToLower(item.Status); s != completed && s != warning &&
-				s != failed && s != errorstr && item.ErrorMessage == "" && len(item.StatusMessages) == 0 {
-				continue
-			} else if _, exists := repeatStomper[item.DownloadID]; exists {
+			if _, exists := repeatStomper[item.DownloadID]; exists {
 				continue
 			}
 
 			repeatStomper[item.DownloadID] = struct{}{}
-			appqueue = append(appqueue, &radarrRecord{QueueRecord: item}) //nolint:wsl
+			stuck := stuckStatus(item.Status, item.ErrorMessage, len(item.StatusMessages))
+
+			tracked := tracker.check(item.DownloadID, item.Sizeleft, stuck)
+			if tracked.StuckSince.IsZero() {
+				continue
+			}
+
+			records = append(records, newStuckRecord(item.ID, item.DownloadID, item.Title,
+				item.Status, item.TrackedDownloadState, stuck, tracked))
+
+			if stuckSince := tracked.StuckSince; tracker.report(tracked) {
+				appqueue = append(appqueue, &radarrRecord{ //nolint:wsl
+					QueueRecord: item,
+					StuckSince:  &stuckSince,
+					Stalled:     tracked.Stalled,
+				})
+			}
 		}
 
-		stuck[instance] = listItem{Name: app.Name, Queue: appqueue, Total: queue.TotalRecords}
+		remediated := c.remediate(ctx, input, &remediator{
+			app:      "Radarr",
+			instance: instance,
+			config:   &app.ExtraConfig,
+			delete:   app.DeleteQueueContext,
+		}, records)
+		tracker.save()
+
+		stuck[instance] = listItem{Name: app.Name, Queue: appqueue, Total: queue.TotalRecords, Remediated: remediated}
 		c.Debugf("Checking Radarr (%d) Queue for Stuck Items, queue size: %d, stuck: %d",
 			instance, len(queue.Records), len(appqueue))
 	}
@@ -128,7 +173,7 @@ func (c *cmd) getFinishedItemsRadarr(_ context.Context) itemList { //nolint:cycl
 	return stuck
 }
 
-func (c *cmd) getFinishedItemsReadarr(_ context.Context) itemList { //nolint:cyclop
+func (c *cmd) getFinishedItemsReadarr(ctx context.Context, input *common.ActionInput) itemList { //nolint:cyclop
 	stuck := make(itemList)
 
 	for idx, app := range c.Apps.Readarr {
@@ -145,22 +190,45 @@ func (c *cmd) getFinishedItemsReadarr(_ context.Context) itemList { //nolint:cyc
 		queue, _ := item.Data.(*readarr.Queue)
 		instance := idx + 1
 		appqueue := []*readarrRecord{}
+		records := []*stuckRecord{}
+		tracker := c.tracker("Readarr", instance, item.Time)
 		// repeatStomper is used to collapse duplicate download IDs.
 		repeatStomper := make(map[string]struct{})
 
 		for _, item := range queue.Records {
-			if s := strings.ToLower(item.Status); s != completed && s != warning &&
-				s != failed && s != errorstr && item.ErrorMessage == "" && len(item.StatusMessages) == 0 {
-				continue
-			} else if _, exists := repeatStomper[item.DownloadID]; exists {
+			if _, exists := repeatStomper[item.DownloadID]; exists {
 				continue
 			}
 
 			repeatStomper[item.DownloadID] = struct{}{}
-			appqueue = append(appqueue, &readarrRecord{QueueRecord: item}) //nolint:wsl
+			stuck := stuckStatus(item.Status, item.ErrorMessage, len(item.StatusMessages))
+
+			tracked := tracker.check(item.DownloadID, item.Sizeleft, stuck)
+			if tracked.StuckSince.IsZero() {
+				continue
+			}
+
+			records = append(records, newStuckRecord(item.ID, item.DownloadID, item.Title,
+				item.Status, item.TrackedDownloadState, stuck, tracked))
+
+			if stuckSince := tracked.StuckSince; tracker.report(tracked) {
+				appqueue = append(appqueue, &readarrRecord{ //nolint:wsl
+					QueueRecord: item,
+					StuckSince:  &stuckSince,
+					Stalled:     tracked.Stalled,
+				})
+			}
 		}
 
-		stuck[instance] = listItem{Name: app.Name, Queue: appqueue, Total: queue.TotalRecords}
+		remediated := c.remediate(ctx, input, &remediator{
+			app:      "Readarr",
+			instance: instance,
+			config:   &app.ExtraConfig,
+			delete:   app.DeleteQueueContext,
+		}, records)
+		tracker.save()
+
+		stuck[instance] = listItem{Name: app.Name, Queue: appqueue, Total: queue.TotalRecords, Remediated: remediated}
 		c.Debugf("Checking Readarr (%d) Queue for Stuck Items, queue size: %d, stuck: %d",
 			instance, len(queue.Records), len(appqueue))
 	}
@@ -168,7 +236,7 @@ func (c *cmd) getFinishedItemsReadarr(_ context.Context) itemList { //nolint:cyc
 	return stuck
 }
 
-func (c *cmd) getFinishedItemsSonarr(_ context.Context) itemList { //nolint:cyclop
+func (c *cmd) getFinishedItemsSonarr(ctx context.Context, input *common.ActionInput) itemList { //nolint:cyclop
 	stuck := make(itemList)
 
 	for idx, app := range c.Apps.Sonarr {
@@ -185,22 +253,45 @@ func (c *cmd) getFinishedItemsSonarr(_ context.Context) itemList { //nolint:cycl
 		queue, _ := cacheItem.Data.(*sonarr.Queue)
 		instance := idx + 1
 		appqueue := []*sonarrRecord{}
+		records := []*stuckRecord{}
+		tracker := c.tracker("Sonarr", instance, cacheItem.Time)
 		// repeatStomper is used to collapse duplicate download IDs.
 		repeatStomper := make(map[string]struct{})
 
 		for _, item := range queue.Records {
-			if s := strings.ToLower(item.Status); s != completed && s != warning &&
-				s != failed && s != errorstr && item.ErrorMessage == "" && len(item.StatusMessages) == 0 {
-				continue
-			} else if _, exists := repeatStomper[item.DownloadID]; exists {
+			if _, exists := repeatStomper[item.DownloadID]; exists {
 				continue
 			}
 
 			repeatStomper[item.DownloadID] = struct{}{}
-			appqueue = append(appqueue, &sonarrRecord{QueueRecord: item}) //nolint:wsl
+			stuck := stuckStatus(item.Status, item.ErrorMessage, len(item.StatusMessages))
+
+			tracked := tracker.check(item.DownloadID, item.Sizeleft, stuck)
+			if tracked.StuckSince.IsZero() {
+				continue
+			}
+
+			records = append(records, newStuckRecord(item.ID, item.DownloadID, item.Title,
+				item.Status, item.TrackedDownloadState, stuck, tracked))
+
+			if stuckSince := tracked.StuckSince; tracker.report(tracked) {
+				appqueue = append(appqueue, &sonarrRecord{ //nolint:wsl
+					QueueRecord: item,
+					StuckSince:  &stuckSince,
+					Stalled:     tracked.Stalled,
+				})
+			}
 		}
 
-		stuck[instance] = listItem{Name: app.Name, Queue: appqueue, Total: queue.TotalRecords}
+		remediated := c.remediate(ctx, input, &remediator{
+			app:      "Sonarr",
+			instance: instance,
+			config:   &app.ExtraConfig,
+			delete:   app.DeleteQueueContext,
+		}, records)
+		tracker.save()
+
+		stuck[instance] = listItem{Name: app.Name, Queue: appqueue, Total: queue.TotalRecords, Remediated: remediated}
 		c.Debugf("Checking Sonarr (%d) Queue for Stuck Items, queue size: %d, stuck: %d",
 			instance, len(queue.Records), len(appqueue))
 	}
@@ -0,0 +1,138 @@
+package starrqueue
+
+/* This file contains the procedures to track how long queue items are stuck, and if they stopped progressing. */
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"golift.io/cnfg"
+)
+
+// trackerKey is the data store key for tracked queue items. It's saved to disk when the data store persists.
+const trackerKey = "stuckTracker"
+
+// stalled is the state given to queue items that stopped progressing. Remediation policies may match it.
+const stalled = "stalled"
+
+// Config controls when stuck items are reported.
+//   - MinAge is how long an item must be stuck before it's reported. Default 0 reports them right away.
+//   - StallChecks reports downloading items as stuck if their size left does not change for this many checks.
+//     Checks happen every 5 minutes, and only count when the queue was refreshed. Default 0 disables this.
+type Config struct {
+	MinAge      cnfg.Duration `json:"minAge"      toml:"min_age"      xml:"min_age"      yaml:"minAge"`
+	StallChecks uint          `json:"stallChecks" toml:"stall_checks" xml:"stall_checks" yaml:"stallChecks"`
+}
+
+// Tracked is a queue item's stuck time and download progress, by download ID.
+type Tracked struct {
+	// StuckSince is when the item was first seen stuck. Zero if it's not stuck.
+	StuckSince time.Time `json:"stuckSince"`
+	// Progress is the last time size left changed.
+	Progress   time.Time `json:"progress"`
+	Sizeleft   float64   `json:"sizeleft"`
+	Unchanged  uint      `json:"unchanged"` // checks without progress.
+	Stalled    bool      `json:"stalled"`
+	Remediated bool      `json:"remediated"`
+	// Checked is when the queue was fetched for the last check. Only a newer queue counts as another check.
+	Checked time.Time `json:"checked"`
+}
+
+// tracker checks the items in one instance's queue.
+type tracker struct {
+	items  map[string]*Tracked
+	prefix string
+	seen   map[string]struct{}
+	now    time.Time
+	queued time.Time // when the checked queue was fetched.
+	config *Config
+}
+
+// tracker returns a copy of the tracked items for an app instance. Call save when the queue was checked.
+// queued is when the queue being checked was fetched, so a stale queue is not counted as another check.
+// This is only used from sendStuckQueues, so the copy needs no lock. The saved items are never changed,
+// because the data store API may be encoding them.
+func (c *cmd) tracker(app string, instance int, queued time.Time) *tracker {
+	items := make(map[string]*Tracked)
+
+	if item := data.Get(trackerKey); item != nil {
+		saved, _ := item.Data.(map[string]*Tracked)
+		for key, tracked := range saved {
+			copied := *tracked
+			items[key] = &copied
+		}
+	}
+
+	return &tracker{
+		items:  items,
+		prefix: fmt.Sprint(app, instance, "-"),
+		seen:   make(map[string]struct{}),
+		now:    time.Now(),
+		queued: queued,
+		config: c.stuckConfig,
+	}
+}
+
+// check updates the tracked progress for a download, and sets or clears its stuck time.
+func (t *tracker) check(downloadID string, sizeleft float64, stuck bool) *Tracked {
+	key := t.prefix + downloadID
+	t.seen[key] = struct{}{}
+
+	item := t.items[key]
+
+	switch {
+	case item == nil:
+		item = &Tracked{Progress: t.now, Sizeleft: sizeleft}
+		t.items[key] = item
+	case !t.queued.After(item.Checked):
+		// The queue was not refreshed since the last check, so there's nothing new to count.
+	case item.Sizeleft == sizeleft:
+		item.Unchanged++
+	default:
+		item.Progress = t.now
+		item.Sizeleft = sizeleft
+		item.Unchanged = 0
+	}
+
+	item.Checked = t.queued
+
+	item.Stalled = t.config.StallChecks > 0 && sizeleft > 0 && item.Unchanged >= t.config.StallChecks
+
+	switch {
+	case !stuck && !item.Stalled:
+		item.StuckSince = time.Time{}
+		item.Remediated = false
+	case !item.StuckSince.IsZero():
+	case stuck:
+		item.StuckSince = t.now
+	default:
+		item.StuckSince = item.Progress // stalled since it last made progress.
+	}
+
+	return item
+}
+
+// report returns true if a stuck item is old enough to report.
+func (t *tracker) report(item *Tracked) bool {
+	return !item.StuckSince.IsZero() && t.now.Sub(item.StuckSince) >= t.config.MinAge.Duration
+}
+
+// save forgets the instance's downloads that are no longer in its queue, and saves the tracked items.
+// Call it after remediation, because the items must not change once they're saved.
+func (t *tracker) save() {
+	for key := range t.items {
+		if _, ok := t.seen[key]; !ok && strings.HasPrefix(key, t.prefix) {
+			delete(t.items, key)
+		}
+	}
+
+	data.Save(trackerKey, t.items)
+}
+
+// stuckStatus returns true if a queue item's status means it's stuck.
+func stuckStatus(status, errorMessage string, messages int) bool {
+	s := strings.ToLower(status)
+	return s == completed || s == warning || s == failed || s == errorstr || errorMessage != "" || messages > 0
+}
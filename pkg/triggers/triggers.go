@@ -5,6 +5,7 @@ package triggers
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"reflect"
 
 	"github.com/Notifiarr/notifiarr/pkg/apps"
@@ -12,11 +13,13 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/snapshot"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/autoupdate"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/backups"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/calendar"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/cfsync"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/commands"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/crontimer"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/dashboard"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/emptytrash"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/fileupload"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/filewatch"
@@ -25,10 +28,19 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/triggers/plexcron"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/snapcron"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/starrqueue"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/workflow"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
 )
 
+// These files are saved next to the config file.
+const (
+	// stateFile contains the last run times for scheduled actions.
+	stateFile = "notifiarr.schedules.json"
+	// dataFile contains the data store keys selected for persistence.
+	dataFile = "notifiarr.data.json"
+)
+
 // Config is the required input data. Everything is mandatory.
 type Config struct {
 	Apps       *apps.Apps
@@ -37,6 +49,12 @@ type Config struct {
 	WatchFiles []*filewatch.WatchFile
 	LogFiles   []string
 	Commands   []*commands.Command
+	CmdQueue   *commands.QueueConfig
+	Workflows  []*workflow.Workflow
+	DataStore  *data.Config
+	StuckItems *starrqueue.Config
+	Archive    *backups.ArchiveConfig
+	BackupDB   *backups.CheckConfig
 	ClientInfo *clientinfo.Config
 	ConfigFile string
 	AutoUpdate string
@@ -52,6 +70,7 @@ type Actions struct {
 	// Order is important here.
 	PlexCron   *plexcron.Action
 	Backups    *backups.Action
+	Calendar   *calendar.Action
 	CFSync     *cfsync.Action
 	CronTimer  *crontimer.Action
 	Dashboard  *dashboard.Action
@@ -64,6 +83,7 @@ type Actions struct {
 	MDbList    *mdblist.Action
 	FileUpload *fileupload.Action
 	AutoUpdate *autoupdate.Action
+	Workflows  *workflow.Action // must be last, it looks up the other triggers.
 }
 
 // New turns a populated Config into a pile of Actions.
@@ -76,25 +96,44 @@ func New(config *Config) *Actions {
 		CI:       config.ClientInfo,
 		Services: config.Services,
 	}
-	plex := plexcron.New(common, config.Apps.Plex)
 
-	return &Actions{
+	dataPath := ""
+	if config.ConfigFile != "" {
+		common.StateFile = filepath.Join(filepath.Dir(config.ConfigFile), stateFile)
+		dataPath = filepath.Join(filepath.Dir(config.ConfigFile), dataFile)
+	}
+
+	plex := plexcron.New(common, config.Apps.Plex)
+	cmds := commands.New(common, config.Commands, config.CmdQueue)
+	queue := starrqueue.New(common, config.StuckItems)
+	actions := &Actions{
 		PlexCron:   plex,
-		Backups:    backups.New(common),
+		Backups:    backups.New(common, config.Archive, config.BackupDB),
+		Calendar:   calendar.New(common),
 		CFSync:     cfsync.New(common),
 		CronTimer:  crontimer.New(common),
-		Dashboard:  dashboard.New(common, plex),
+		Dashboard:  dashboard.New(common, plex, queue),
 		FileWatch:  filewatch.New(common, config.WatchFiles, config.LogFiles),
 		Gaps:       gaps.New(common),
 		SnapCron:   snapcron.New(common),
-		StarrQueue: starrqueue.New(common),
-		Commands:   commands.New(common, config.Commands),
+		StarrQueue: queue,
+		Commands:   cmds,
 		EmptyTrash: emptytrash.New(common),
 		MDbList:    mdblist.New(common),
 		FileUpload: fileupload.New(common),
 		Config:     common,
 		AutoUpdate: autoupdate.New(common, config.AutoUpdate, config.ConfigFile, config.UnstableCh),
+		Workflows:  workflow.New(common, config.Workflows, cmds),
 	}
+
+	// The actions register the data they persist, so this must run after they are created.
+	if count, err := data.Setup(config.DataStore, dataPath); err != nil {
+		common.Errorf("Loading data store: %v", err)
+	} else if count > 0 {
+		common.Printf("==> Data Store: loaded %d items from %s", count, dataPath)
+	}
+
+	return actions
 }
 
 // These methods use reflection so they never really need to be updated.
@@ -139,4 +178,10 @@ func (a *Actions) Stop(event website.EventType) {
 			action.Stop()
 		}
 	}
+
+	if count, err := data.Snapshot(); err != nil {
+		a.Errorf("Saving data store: %v", err)
+	} else if count > 0 {
+		a.Debugf("Saved %d data store items to disk.", count)
+	}
 }
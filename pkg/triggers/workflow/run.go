@@ -0,0 +1,105 @@
+package workflow
+
+/* This file contains the procedures that run a workflow's steps, and log their timing. */
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+)
+
+// run executes each step in order, and logs the outcome and elapsed time of each.
+func (c *cmd) run(ctx context.Context, input *common.ActionInput, workflow *Workflow) {
+	start := time.Now()
+	failed := false // outcome of the last step that ran.
+	ran, fails := 0, 0
+
+	c.Printf("[%s requested] Workflow '%s' started, steps: %d", input.Type, workflow.Name, len(workflow.Steps))
+
+	for idx, step := range workflow.Steps {
+		if ctx.Err() != nil {
+			c.Errorf("[%s requested] Workflow '%s' stopped before step %d/%d (%s): %v",
+				input.Type, workflow.Name, idx+1, len(workflow.Steps), step, ctx.Err())
+			break
+		}
+
+		if !step.runs(failed) {
+			c.Printf("[%s requested] Workflow '%s' step %d/%d (%s) skipped, previous step failed: %v",
+				input.Type, workflow.Name, idx+1, len(workflow.Steps), step, failed)
+			continue
+		}
+
+		stepStart := time.Now()
+		err := c.runStep(ctx, input, step)
+		elapsed := time.Since(stepStart).Round(time.Millisecond)
+		failed = err != nil
+		ran++
+
+		if failed {
+			fails++
+			c.Errorf("[%s requested] Workflow '%s' step %d/%d (%s) failed (elapsed: %s): %v",
+				input.Type, workflow.Name, idx+1, len(workflow.Steps), step, elapsed, err)
+		} else {
+			c.Printf("[%s requested] Workflow '%s' step %d/%d (%s) succeeded (elapsed: %s)",
+				input.Type, workflow.Name, idx+1, len(workflow.Steps), step, elapsed)
+		}
+	}
+
+	c.Printf("[%s requested] Workflow '%s' finished (elapsed: %s), steps run: %d, failed: %d, skipped: %d",
+		input.Type, workflow.Name, time.Since(start).Round(time.Millisecond), ran, fails, len(workflow.Steps)-ran)
+}
+
+// runStep runs a single trigger or command step and waits for it to finish.
+func (c *cmd) runStep(ctx context.Context, input *common.ActionInput, step *Step) error {
+	timeout := step.Timeout.Duration
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stepInput := &common.ActionInput{Type: input.Type, Args: step.Args}
+
+	if step.Trigger != "" {
+		if err := c.ExecWait(ctx, stepInput, common.TriggerName(step.Trigger)); err != nil {
+			return fmt.Errorf("trigger: %w", err)
+		}
+
+		return nil
+	}
+
+	cmd := c.command(step.Command)
+	if cmd == nil {
+		return fmt.Errorf("%w: %s", ErrNoCommand, step.Command)
+	}
+
+	if _, err := cmd.RunNow(ctx, stepInput); err != nil {
+		return fmt.Errorf("command: %w", err)
+	}
+
+	return nil
+}
+
+// runs returns true if the step should run, based on the outcome of the previous step.
+func (s *Step) runs(failed bool) bool {
+	switch s.When {
+	case WhenAlways:
+		return true
+	case WhenFailure:
+		return failed
+	default:
+		return !failed
+	}
+}
+
+// String returns the step's trigger or command.
+func (s *Step) String() string {
+	if s.Command != "" {
+		return "command: " + s.Command
+	}
+
+	return "trigger: " + s.Trigger
+}
@@ -0,0 +1,168 @@
+// Package workflow runs named pipelines of triggers and commands.
+// Each step may depend on the outcome of the step before it.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/commands"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"golift.io/cnfg"
+)
+
+// Errors produced by this package.
+var (
+	ErrNoWorkflow = errors.New("workflow not found")
+	ErrRunning    = errors.New("workflow is already running")
+	ErrDisabled   = errors.New("workflow is disabled due to an error")
+	ErrStep       = errors.New("invalid workflow step")
+	ErrNoCommand  = errors.New("command not found")
+)
+
+// defaultTimeout is how long a step may run if it has no timeout.
+const defaultTimeout = 10 * time.Minute
+
+// When is the condition for running a step, based on the outcome of the step that ran before it.
+type When string
+
+// These are the supported step conditions.
+const (
+	WhenSuccess When = ""        // Run if the previous step succeeded. This is the default.
+	WhenFailure When = "failure" // Run if the previous step failed.
+	WhenAlways  When = "always"  // Always run.
+)
+
+// Workflow is a named list of steps, from the config file.
+type Workflow struct {
+	Name  string  `json:"name"  toml:"name"  xml:"name"  yaml:"name"`
+	Steps []*Step `json:"steps" toml:"step"  xml:"step"  yaml:"steps"`
+	mu    sync.Mutex
+	err   error // setup error disables the workflow.
+}
+
+// Step runs a trigger or a command. Provide one or the other.
+type Step struct {
+	// Trigger is the name of a trigger, as shown on the triggers page or the triggers API.
+	Trigger string `json:"trigger" toml:"trigger" xml:"trigger" yaml:"trigger"`
+	// Command is the name or hash of a custom command.
+	Command string `json:"command" toml:"command" xml:"command" yaml:"command"`
+	// Args are passed to the command.
+	Args    []string      `json:"args"    toml:"args"    xml:"args"    yaml:"args"`
+	When    When          `json:"when"    toml:"when"    xml:"when"    yaml:"when"`
+	Timeout cnfg.Duration `json:"timeout" toml:"timeout" xml:"timeout" yaml:"timeout"`
+}
+
+// Action contains the exported methods for this package.
+type Action struct {
+	cmd *cmd
+}
+
+type cmd struct {
+	*common.Config
+	commands  *commands.Action
+	workflows []*Workflow
+}
+
+// New configures the library.
+func New(config *common.Config, workflows []*Workflow, commands *commands.Action) *Action {
+	return &Action{cmd: &cmd{Config: config, commands: commands, workflows: workflows}}
+}
+
+// Create validates the workflows. Invalid workflows are disabled.
+func (a *Action) Create() {
+	for _, workflow := range a.cmd.workflows {
+		if workflow.err = a.cmd.validate(workflow); workflow.err != nil {
+			a.cmd.Errorf("Workflow '%s' disabled: %v", workflow.Name, workflow.err)
+		}
+	}
+
+	if len(a.cmd.workflows) > 0 {
+		a.cmd.Printf("==> Workflows: %d provided", len(a.cmd.workflows))
+	}
+}
+
+// List returns the configured workflows.
+func (a *Action) List() []*Workflow {
+	return a.cmd.workflows
+}
+
+// Run starts a workflow by name in a go routine. Returns an error if it cannot start.
+func (a *Action) Run(input *common.ActionInput, name string) error {
+	workflow := a.cmd.get(name)
+	if workflow == nil {
+		return fmt.Errorf("%w: %s", ErrNoWorkflow, name)
+	} else if workflow.err != nil {
+		return fmt.Errorf("%w: %w", ErrDisabled, workflow.err)
+	} else if !workflow.mu.TryLock() {
+		return fmt.Errorf("%w: %s", ErrRunning, name)
+	}
+
+	// Stops with the timer loop, so a reload does not leave steps running against old triggers.
+	ctx := a.cmd.StopContext()
+
+	go func() {
+		defer workflow.mu.Unlock()
+		defer a.cmd.CapturePanic()
+		a.cmd.run(ctx, input, workflow)
+	}()
+
+	return nil
+}
+
+func (c *cmd) get(name string) *Workflow {
+	for _, workflow := range c.workflows {
+		if strings.EqualFold(workflow.Name, name) {
+			return workflow
+		}
+	}
+
+	return nil
+}
+
+func (c *cmd) validate(workflow *Workflow) error {
+	if len(workflow.Steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrStep)
+	}
+
+	for idx, step := range workflow.Steps {
+		var err error
+
+		switch {
+		case step.Trigger == "" && step.Command == "":
+			err = fmt.Errorf("%w: provide a trigger or a command", ErrStep)
+		case step.Trigger != "" && step.Command != "":
+			err = fmt.Errorf("%w: provide a trigger or a command, not both", ErrStep)
+		case step.Command != "" && c.command(step.Command) == nil:
+			err = fmt.Errorf("%w: %s", ErrNoCommand, step.Command)
+		case step.Trigger != "" && c.Get(common.TriggerName(step.Trigger)) == nil:
+			err = fmt.Errorf("%w: %s", common.ErrNoTrigger, step.Trigger)
+		case step.When != WhenSuccess && step.When != WhenFailure && step.When != WhenAlways && step.When != "success":
+			err = fmt.Errorf("%w: when '%s', valid: success, failure, always", ErrStep, step.When)
+		}
+
+		if err != nil {
+			return fmt.Errorf("step %d: %w", idx+1, err)
+		}
+	}
+
+	return nil
+}
+
+// command returns a command by name or hash.
+func (c *cmd) command(name string) *commands.Command {
+	if cmd := c.commands.GetByHash(name); cmd != nil {
+		return cmd
+	}
+
+	for _, cmd := range c.commands.List() {
+		if cmd.Name == name {
+			return c.commands.GetByHash(cmd.Hash)
+		}
+	}
+
+	return nil
+}
@@ -60,11 +60,15 @@ type MuleryServer struct {
 
 // CronConfig defines a custom GET timer from the website.
 // Used to offload crons to clients.
+// Cron and AtTime are used instead of Interval when they are not empty.
 type CronConfig struct {
 	Name     string        `json:"name"`     // name of action.
 	Interval cnfg.Duration `json:"interval"` // how often to GET this URI.
 	URI      string        `json:"endpoint"` // endpoint for the URI.
 	Desc     string        `json:"description"`
+	Cron     string        `json:"cron"`     // cron expression for when to GET this URI.
+	AtTime   string        `json:"atTime"`   // time of day to GET this URI, ie. 03:00.
+	Timezone string        `json:"timezone"` // timezone for Cron and AtTime, default is local.
 }
 
 // SyncConfig is the configuration returned from the notifiarr website for CF/RP TraSH sync.
@@ -88,6 +92,10 @@ type MdbListConfig struct {
 // DashConfig is the configuration returned from the notifiarr website for the dashboard configuration.
 type DashConfig struct {
 	Interval cnfg.Duration `json:"interval"` // how often to fire.
+	// Root folders with less free space than this percent of the disk are low on space. Default is 5.
+	LowSpacePct int64 `json:"lowSpacePct"`
+	// Used instead of LowSpacePct when the disk size is unknown. Default is 10 GB.
+	LowSpaceBytes int64 `json:"lowSpaceBytes"`
 }
 
 // AppConfig is the data that comes from the website for each Starr app.
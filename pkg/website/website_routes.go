@@ -129,6 +129,7 @@ const (
 	DashRoute     Route = notifiRoute + "/dashboard"
 	StuckRoute    Route = notifiRoute + "/stuck"
 	DownloadRoute Route = notifiRoute + "/downloads"
+	DupesRoute    Route = notifiRoute + "/duplicates"
 	PlexRoute     Route = notifiRoute + "/plex"
 	SnapRoute     Route = notifiRoute + "/snapshot"
 	SvcRoute      Route = notifiRoute + "/services"